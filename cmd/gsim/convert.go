@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gravity-sim/pkg/trajectory"
+)
+
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	out := fs.String("o", "", "plik wyjściowy (domyślnie stdout)")
+	format := fs.String("format", "", "format wyjściowy: csv lub ndjson (domyślnie z rozszerzenia -o, inaczej csv)")
+	from := fs.Int64("from", 0, "pierwszy krok do zapisania")
+	to := fs.Int64("to", -1, "ostatni krok do zapisania (-1 = do końca)")
+	every := fs.Int("every", 1, "zapis co K-tej klatki")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Użycie: gsim convert [flagi] plik.gtraj")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("podaj jeden plik trajektorii")
+	}
+	if *every <= 0 {
+		return fmt.Errorf("-every musi być dodatnie")
+	}
+
+	rd, err := trajectory.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+	if rd.Partial() {
+		fmt.Fprintf(os.Stderr, "uwaga: %s nie ma indeksu (przerwany zapis), odczytano %d klatek\n", fs.Arg(0), rd.Len())
+	}
+
+	f := trajectory.CSV
+	switch {
+	case *format != "":
+		f, err = trajectory.ParseFormat(*format)
+	case *out != "":
+		f, err = trajectory.ParseFormat(filepath.Ext(*out))
+	}
+	if err != nil {
+		return err
+	}
+	if f == trajectory.Binary {
+		return fmt.Errorf("convert zapisuje tylko formaty tekstowe (csv, ndjson)")
+	}
+
+	var w trajectory.FrameWriter
+	if *out == "" {
+		w, err = trajectory.New(os.Stdout, f)
+	} else {
+		w, err = trajectory.Create(*out, f)
+	}
+	if err != nil {
+		return err
+	}
+
+	// indeks pozwala zacząć od razu od właściwej klatki
+	start, err := rd.SearchStep(*from - 1)
+	if err != nil {
+		return err
+	}
+	for i := start + 1; i < rd.Len(); i += *every {
+		fr, err := rd.Frame(i)
+		if err != nil {
+			w.Close()
+			return err
+		}
+		if *to >= 0 && fr.Step > *to {
+			break
+		}
+		if err := w.Write(rd.Header(), fr); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gravity-sim/pkg/assets"
+	"gravity-sim/pkg/generator"
+	"gravity-sim/pkg/simulation"
+)
+
+func runGenerate(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("podaj rodzaj sceny: plummer, disk lub belt")
+	}
+	kind, args := args[0], args[1:]
+	fs := flag.NewFlagSet("generate "+kind, flag.ExitOnError)
+	out := fs.String("o", "", "plik wyjściowy JSON (domyślnie standardowe wyjście)")
+	name := fs.String("name", "", "nazwa sceny")
+	seed := fs.Uint64("seed", 1, "ziarno generatora liczb losowych")
+	dt := fs.Float64("dt", 0, "krok czasowy (0 = dobrany automatycznie)")
+
+	var build func() (simulation.EnvironmentConfig, error)
+	switch kind {
+	case "plummer":
+		var p generator.PlummerParams
+		fs.IntVar(&p.N, "n", 200, "liczba ciał")
+		fs.Float64Var(&p.Mass, "mass", 1e5, "masa całkowita gromady")
+		fs.Float64Var(&p.ScaleRadius, "a", 100, "promień skali Plummera")
+		fs.Float64Var(&p.Cutoff, "cutoff", 10, "maksymalny promień w jednostkach a")
+		fs.Float64Var(&p.BodyRadius, "radius", 2, "promień rysowanego ciała")
+		fs.StringVar(&p.Color, "color", "#ffe4b5", "kolor ciał (HEX)")
+		gravityFlags(fs, &p.Units, &p.Softening)
+		build = func() (simulation.EnvironmentConfig, error) {
+			p.Seed, p.Dt = *seed, *dt
+			return generator.Plummer(p), nil
+		}
+	case "disk":
+		var p generator.DiskParams
+		fs.IntVar(&p.N, "n", 400, "liczba gwiazd dysku")
+		fs.Float64Var(&p.DiskMass, "mass", 1e5, "masa dysku")
+		fs.Float64Var(&p.ScaleLength, "rd", 120, "długość skali dysku")
+		fs.Float64Var(&p.Cutoff, "cutoff", 5, "maksymalny promień dysku w jednostkach rd")
+		fs.IntVar(&p.BulgeN, "bulge-n", 50, "liczba gwiazd zgrubienia")
+		fs.Float64Var(&p.BulgeMass, "bulge-mass", 2e4, "masa zgrubienia")
+		fs.Float64Var(&p.BulgeRadius, "bulge-a", 0, "promień skali zgrubienia (0 = rd/5)")
+		fs.Float64Var(&p.CentralMass, "central-mass", 5e4, "masa ciała centralnego (0 = brak)")
+		fs.Float64Var(&p.Dispersion, "dispersion", 0.05, "względna dyspersja prędkości dysku")
+		fs.BoolVar(&p.Retrograde, "retrograde", false, "obrót zgodny z ruchem wskazówek zegara")
+		fs.Float64Var(&p.BodyRadius, "radius", 2, "promień rysowanego ciała")
+		gravityFlags(fs, &p.Units, &p.Softening)
+		build = func() (simulation.EnvironmentConfig, error) {
+			p.Seed, p.Dt = *seed, *dt
+			return generator.Disk(p), nil
+		}
+	case "belt":
+		var p generator.BeltParams
+		scene := fs.String("scene", "", "scena wejściowa (plik JSON lub nazwa z gsim list), do której dopisany zostanie pas (wymagane)")
+		fs.IntVar(&p.Center, "center", 0, "indeks ciała centralnego")
+		fs.IntVar(&p.Count, "n", 100, "liczba ciał pasa")
+		fs.Float64Var(&p.Inner, "inner", 0, "promień wewnętrzny (wymagane)")
+		fs.Float64Var(&p.Outer, "outer", 0, "promień zewnętrzny (domyślnie równy wewnętrznemu)")
+		fs.Float64Var(&p.MassMin, "mass-min", 0.01, "minimalna masa ciała")
+		fs.Float64Var(&p.MassMax, "mass-max", 0.01, "maksymalna masa ciała")
+		fs.Float64Var(&p.Eccentricity, "ecc", 0.02, "rozrzut prędkości jako ułamek prędkości kołowej")
+		fs.BoolVar(&p.Retrograde, "retrograde", false, "obrót zgodny z ruchem wskazówek zegara")
+		fs.Float64Var(&p.BodyRadius, "radius", 1.5, "promień rysowanego ciała")
+		fs.StringVar(&p.Color, "color", "#a0a0a0", "kolor ciał (HEX)")
+		build = func() (simulation.EnvironmentConfig, error) {
+			if *scene == "" {
+				return simulation.EnvironmentConfig{}, fmt.Errorf("flaga -scene jest wymagana")
+			}
+			src := assets.FromFile(*scene)
+			if _, err := os.Stat(*scene); err != nil {
+				if src, err = assets.Find(*scene); err != nil {
+					return simulation.EnvironmentConfig{}, err
+				}
+			}
+			env, err := src.Environment()
+			if err != nil {
+				return env, err
+			}
+			if p.Outer == 0 {
+				p.Outer = p.Inner
+			}
+			p.Seed = *seed
+			if *dt > 0 {
+				env.Dt = *dt
+			}
+			err = generator.Belt(&env, p)
+			return env, err
+		}
+	default:
+		return fmt.Errorf("nieznany rodzaj sceny %q (plummer, disk, belt)", kind)
+	}
+	fs.Parse(args)
+
+	env, err := build()
+	if err != nil {
+		return err
+	}
+	if *name != "" {
+		env.Name = *name
+	}
+	if *out == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(env)
+	}
+	return simulation.SaveConfig(*out, env)
+}
+
+// gravityFlags dodaje flagi -units i -softening; prędkości generowanej sceny są liczone
+// z wynikających z nich G i softeningu, a oba ustawienia trafiają do pliku sceny
+func gravityFlags(fs *flag.FlagSet, u **simulation.UnitsConfig, softening **float64) {
+	fs.Func("units", "układ jednostek sceny: długość,masa,czas (np. AU,Msun,yr) albo henon (domyślnie jednostki umowne)", func(v string) error {
+		cfg := &simulation.UnitsConfig{System: v}
+		if parts := strings.Split(v, ","); len(parts) == 3 {
+			cfg = &simulation.UnitsConfig{Length: parts[0], Mass: parts[1], Time: parts[2]}
+		} else if v != "henon" {
+			return fmt.Errorf("oczekiwano długość,masa,czas albo henon")
+		}
+		if _, err := (simulation.EnvironmentConfig{Units: cfg}).UnitSystem(); err != nil {
+			return err
+		}
+		*u = cfg
+		return nil
+	})
+	fs.Func("softening", "softening sceny (domyślnie 5 w jednostkach umownych, 0 przy -units)", func(v string) error {
+		eps, err := strconv.ParseFloat(v, 64)
+		if err != nil || eps < 0 {
+			return fmt.Errorf("oczekiwano nieujemnej liczby")
+		}
+		*softening = &eps
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gravity-sim/pkg/ephemeris"
+	"gravity-sim/pkg/simulation"
+)
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	out := fs.String("o", "", "plik wyjściowy JSON (domyślnie standardowe wyjście)")
+	name := fs.String("name", "", "nazwa sceny")
+	var u simulation.UnitsConfig
+	fs.StringVar(&u.Length, "length", ephemeris.DefaultLength, "jednostka długości sceny (np. AU, \"0.01 AU\", km)")
+	fs.StringVar(&u.Mass, "mass", ephemeris.DefaultMass, "jednostka masy sceny (np. Msun, Mearth, kg)")
+	fs.StringVar(&u.Time, "time", ephemeris.DefaultTime, "jednostka czasu sceny (np. day, yr, s)")
+	dt := fs.Float64("dt", ephemeris.DefaultDt, "krok czasowy w jednostkach sceny")
+	epoch := fs.Float64("epoch", 0, "epoka JD (0 = pierwszy wiersz każdego pliku)")
+	noCenter := fs.Bool("no-center", false, "nie dodawaj ciała centralnego")
+	bary := fs.Bool("barycenter", false, "przenieś scenę do układu środka masy")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Użycie: gsim import [flagi] plik_horizons...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("podaj co najmniej jeden plik Horizons")
+	}
+
+	var states []ephemeris.State
+	for _, path := range fs.Args() {
+		st, err := ephemeris.ReadFile(path, *epoch)
+		if err != nil {
+			return err
+		}
+		states = append(states, st)
+	}
+	env, warnings, err := ephemeris.Import(states, ephemeris.Options{
+		Name:       *name,
+		Units:      u,
+		Dt:         *dt,
+		AddCenter:  !*noCenter,
+		Barycenter: *bary,
+	})
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		log.Printf("uwaga: %s", w)
+	}
+	if *out == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(env)
+	}
+	return simulation.SaveConfig(*out, env)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"gravity-sim/pkg/assets"
+)
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Użycie: gsim list")
+		fmt.Fprintf(os.Stderr, "Wypisuje sceny z katalogów %s i sceny wbudowane.\n", assets.PathEnv)
+	}
+	fs.Parse(args)
+
+	scenes, err := assets.List()
+	if err != nil {
+		return err
+	}
+	if dirs := assets.SearchPath(); len(dirs) > 0 {
+		fmt.Printf("%s:", assets.PathEnv)
+		for _, d := range dirs {
+			fmt.Printf(" %s", d)
+		}
+		fmt.Println()
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAZWA\tSCENA\tPLIK")
+	seen := make(map[string]bool)
+	for _, s := range scenes {
+		title := ""
+		if env, err := s.Read(); err != nil {
+			title = "(błąd: " + err.Error() + ")"
+		} else {
+			title = env.Name
+		}
+		file := s.String()
+		if seen[s.Name] {
+			file += " (przesłonięta)"
+		}
+		seen[s.Name] = true
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, title, file)
+	}
+	return tw.Flush()
+}
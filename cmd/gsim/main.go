@@ -0,0 +1,57 @@
+// Polecenie gsim to narzędzie wiersza poleceń do pracy ze scenami bez okna graficznego.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// command - podpolecenie gsim
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"generate", "generuje proceduralną scenę (plummer, disk, belt)", runGenerate},
+	{"run", "liczy symulację bez okna i zapisuje trajektorie oraz diagnostykę", runRun},
+	{"serve", "liczy symulację bez okna sterowaną przez lokalne API HTTP", runServe},
+	{"import", "importuje wektory stanu z plików JPL Horizons do sceny", runImport},
+	{"convert", "konwertuje binarną trajektorię (.gtraj) do CSV lub NDJSON", runConvert},
+	{"list", "wypisuje dostępne sceny (wbudowane i z GRAVITY_SIM_PATH)", runList},
+	{"validate", "sprawdza pliki scen i wypisuje błędy oraz ostrzeżenia", runValidate},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Użycie: gsim <polecenie> [flagi]")
+	fmt.Fprintln(os.Stderr, "\nPolecenia:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name, args := os.Args[1], os.Args[2:]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(args); err != nil {
+				log.Fatalf("gsim %s: %v", name, err)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "nieznane polecenie %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
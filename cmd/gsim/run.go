@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"gravity-sim/pkg/assets"
+	"gravity-sim/pkg/export"
+	"gravity-sim/pkg/simulation"
+	"gravity-sim/pkg/trajectory"
+)
+
+func runRun(args []string) error {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	envName := fs.String("env", "", "nazwa sceny z GRAVITY_SIM_PATH lub wbudowanej (gsim list)")
+	configPath := fs.String("config", "", "ścieżka do pliku sceny JSON")
+	resume := fs.String("resume", "", "wznów z pliku stanu (checkpoint)")
+	steps := fs.Int64("steps", 0, "liczba kroków do wykonania (0 = bez limitu)")
+	until := fs.Float64("until", 0, "czas symulacji, do którego liczyć (0 = bez limitu)")
+	outDir := fs.String("out", "out", "katalog wyjściowy")
+	every := fs.Int("every", 10, "zapis trajektorii co K kroków")
+	format := fs.String("format", "csv", "format trajektorii: csv, ndjson lub gtraj (binarny)")
+	f32 := fs.Bool("float32", false, "gtraj: zapis wartości jako float32")
+	delta := fs.Bool("delta", false, "gtraj: kodowanie różnicowe kolejnych klatek")
+	compress := fs.Bool("compress", false, "gtraj: kompresja bloków (compress/flate)")
+	exportFormat := fs.String("export", "", "dodatkowy eksport do wizualizacji: vtk (plik na klatkę) lub xyz (extended XYZ)")
+	exportEvery := fs.Int("export-every", 0, "eksport co K kroków (0 = jak -every)")
+	diagEvery := fs.Int("diag-every", 0, "zapis diagnostyki co K kroków (0 = jak -every)")
+	checkpoint := fs.String("checkpoint", "", "plik stanu zapisywany na końcu i po Ctrl+C (domyślnie <out>/final.ckpt.json)")
+	progress := fs.Duration("progress", 2*time.Second, "odstęp raportów postępu (0 = bez raportów)")
+	fs.Parse(args)
+
+	if *steps <= 0 && *until <= 0 {
+		return fmt.Errorf("podaj -steps lub -until")
+	}
+	if *every <= 0 {
+		return fmt.Errorf("-every musi być dodatnie")
+	}
+	trajFormat, err := trajectory.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	if *exportEvery <= 0 {
+		*exportEvery = *every
+	}
+	if *diagEvery <= 0 {
+		*diagEvery = *every
+	}
+	if *checkpoint == "" {
+		*checkpoint = filepath.Join(*outDir, "final.ckpt.json")
+	}
+
+	sim, err := openSimulation(*envName, *configPath, *resume)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return err
+	}
+
+	trajPath := filepath.Join(*outDir, "trajectory."+string(trajFormat))
+	var traj trajectory.Writer
+	if trajFormat == trajectory.Binary {
+		traj, err = trajectory.CreateBinary(trajPath, trajectory.BinaryOptions{Float32: *f32, Delta: *delta, Compress: *compress})
+	} else {
+		traj, err = trajectory.Create(trajPath, trajFormat)
+	}
+	if err != nil {
+		return err
+	}
+	// po dołączeniu plik zamyka Recorder (przy odłączeniu); wcześniejszy błąd zamyka go tutaj
+	trajAttached := false
+	defer func() {
+		if !trajAttached {
+			traj.Close()
+		}
+	}()
+
+	// zapis trajektorii, eksport i diagnostyka są obserwatorami kroków symulacji
+	observers := []simulation.Observer{&trajectory.Recorder{W: traj, Every: *every}}
+	if *exportFormat != "" {
+		f, err := export.ParseFormat(*exportFormat)
+		if err != nil {
+			return err
+		}
+		series, err := export.NewSeries(filepath.Join(*outDir, "snapshot"), f)
+		if err != nil {
+			return err
+		}
+		defer series.Close()
+		observers = append(observers, &trajectory.Recorder{W: series, Every: *exportEvery})
+	}
+
+	diag, err := os.Create(filepath.Join(*outDir, "diagnostics.csv"))
+	if err != nil {
+		return err
+	}
+	defer diag.Close()
+	observers = append(observers, &simulation.DiagnosticsLog{W: diag, Every: *diagEvery})
+
+	startStep := sim.Step
+	started := time.Now()
+	if *progress > 0 {
+		lastReport := started
+		observers = append(observers, simulation.StepFunc(func(sim *simulation.Simulator, _ float64) error {
+			if time.Since(lastReport) >= *progress {
+				lastReport = time.Now()
+				reportProgress(sim, startStep, *steps, *until, started)
+			}
+			return nil
+		}))
+	}
+
+	// detachAll odłącza wszystkich obserwatorów - zamyka pliki trajektorii i eksportu oraz zapisuje
+	// bufor diagnostyki - także po błędzie jednego z nich
+	var detach []func() error
+	detachAll := func() error {
+		var errs []error
+		for _, d := range detach {
+			errs = append(errs, d())
+		}
+		detach = nil
+		return errors.Join(errs...)
+	}
+	for _, o := range observers {
+		d, err := sim.Attach(o)
+		if err != nil {
+			return errors.Join(err, detachAll())
+		}
+		detach = append(detach, d)
+		trajAttached = true // observers[0] to zapis trajektorii
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	res, err := sim.Run(ctx, simulation.Until{Time: *until, Steps: *steps})
+	if res.Reason == simulation.StopCanceled {
+		// Ctrl+C - zwykłe zakończenie z zapisem stanu
+		err = nil
+	}
+	// stan końcowy jest zapisywany zawsze, także po błędzie obserwatora lub odłączania
+	err = errors.Join(err, detachAll())
+	if serr := sim.Save(*checkpoint); serr != nil {
+		return errors.Join(err, serr)
+	}
+	if err != nil {
+		log.Printf("błąd w kroku %d (t=%g), stan zapisano do %s", sim.Step, res.Time, *checkpoint)
+		return err
+	}
+	if res.Reason == simulation.StopCanceled {
+		log.Printf("przerwano w kroku %d (t=%g), stan zapisano do %s", sim.Step, res.Time, *checkpoint)
+		return nil
+	}
+	log.Printf("zakończono: %d kroków, t=%g, %d ciał, stan zapisano do %s", res.Steps, res.Time, res.Diagnostics.Bodies, *checkpoint)
+	return nil
+}
+
+// openSimulation wczytuje scenę po nazwie, ze ścieżki albo z pliku stanu
+func openSimulation(envName, configPath, resume string) (*simulation.Simulator, error) {
+	switch {
+	case resume != "":
+		return simulation.Restore(resume)
+	case configPath != "":
+		return simulation.LoadConfig(configPath)
+	case envName != "":
+		scene, err := assets.Find(envName)
+		if err != nil {
+			return nil, err
+		}
+		return scene.Load()
+	}
+	return nil, fmt.Errorf("podaj -env, -config lub -resume")
+}
+
+func reportProgress(sim *simulation.Simulator, startStep, steps int64, until float64, started time.Time) {
+	done := sim.Step - startStep
+	rate := float64(done) / time.Since(started).Seconds()
+	pct := ""
+	switch {
+	case steps > 0:
+		pct = fmt.Sprintf(" (%.1f%%)", 100*float64(done)/float64(steps))
+	case until > 0:
+		pct = fmt.Sprintf(" (%.1f%%)", 100*sim.Time/until)
+	}
+	log.Printf("krok %d%s  t=%g  ciał %d  %.0f kroków/s", sim.Step, pct, sim.Time, len(sim.Bodies), rate)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gravity-sim/pkg/api"
+	"gravity-sim/pkg/engine"
+)
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	envName := fs.String("env", "", "nazwa sceny z GRAVITY_SIM_PATH lub wbudowanej (gsim list)")
+	configPath := fs.String("config", "", "ścieżka do pliku sceny JSON")
+	resume := fs.String("resume", "", "wznów z pliku stanu (checkpoint)")
+	addr := fs.String("addr", "127.0.0.1:8080", "adres serwera API HTTP (tylko pętla zwrotna)")
+	speed := fs.Int("speed", 1, "liczba kroków na takt")
+	tick := fs.Duration("tick", time.Second/60, "odstęp taktów")
+	paused := fs.Bool("paused", false, "start w pauzie (POST /resume wznawia)")
+	checkpoint := fs.String("checkpoint", "gravity-sim.ckpt.json", "plik stanu zapisywany przez POST /checkpoint")
+	fs.Parse(args)
+
+	if *speed <= 0 || *tick <= 0 {
+		return fmt.Errorf("-speed i -tick muszą być dodatnie")
+	}
+	sim, err := openSimulation(*envName, *configPath, *resume)
+	if err != nil {
+		return err
+	}
+	eng := engine.New(sim)
+	defer eng.Stop()
+	runner := api.NewRunner(eng)
+	runner.Steps, runner.Tick = *speed, *tick
+	runner.SetPaused(context.Background(), *paused)
+	srv := api.NewServer(runner)
+	srv.CheckpointPath = *checkpoint
+
+	ln, err := api.Listen(*addr)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	hs := &http.Server{Handler: srv}
+	go func() {
+		<-ctx.Done()
+		hs.Shutdown(context.Background())
+	}()
+	go runner.Run(ctx)
+
+	log.Printf("API symulacji %q: http://%s (Ctrl+C kończy)", eng.Snapshot().Name, ln.Addr())
+	if err := hs.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	snap := eng.Snapshot()
+	log.Printf("zakończono w kroku %d (t=%g)", snap.Step, snap.Time)
+	return nil
+}
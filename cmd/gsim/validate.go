@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gravity-sim/pkg/assets"
+	"gravity-sim/pkg/simulation"
+)
+
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	quiet := fs.Bool("q", false, "pomiń ostrzeżenia, wypisuj tylko błędy")
+	envName := fs.String("env", "", "nazwa sceny do sprawdzenia z GRAVITY_SIM_PATH lub wbudowanej (gsim list)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Użycie: gsim validate [flagi] scena.json...")
+		fmt.Fprintln(os.Stderr, "       gsim validate [flagi] -env nazwa [scena.json...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	scenes := make([]assets.Scene, 0, fs.NArg()+1)
+	if *envName != "" {
+		scene, err := assets.Find(*envName)
+		if err != nil {
+			return err
+		}
+		scenes = append(scenes, scene)
+	}
+	for _, path := range fs.Args() {
+		scenes = append(scenes, assets.FromFile(path))
+	}
+	if len(scenes) == 0 {
+		fs.Usage()
+		return fmt.Errorf("podaj co najmniej jeden plik sceny albo -env")
+	}
+
+	failed := 0
+	for _, scene := range scenes {
+		issues, err := scene.Validate()
+		if err != nil {
+			fmt.Printf("%s: error: %v\n", scene, err)
+			failed++
+			continue
+		}
+		for _, is := range issues {
+			if *quiet && is.Severity != simulation.SeverityError {
+				continue
+			}
+			fmt.Printf("%s: %s\n", scene, is)
+		}
+		if simulation.HasErrors(issues) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d z %d plików zawiera błędy", failed, len(scenes))
+	}
+	return nil
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"image/color"
 	"log"
 	"math"
-	"os"
-	"path/filepath"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
@@ -16,8 +21,14 @@ import (
 
 	"golang.org/x/image/font/basicfont"
 
+	"gravity-sim/pkg/api"
+	"gravity-sim/pkg/assets"
+	"gravity-sim/pkg/engine"
+	"gravity-sim/pkg/export"
 	"gravity-sim/pkg/physics"
 	"gravity-sim/pkg/simulation"
+	"gravity-sim/pkg/trajectory"
+	"gravity-sim/pkg/units"
 )
 
 const (
@@ -38,7 +49,18 @@ const (
 	graphW = 360
 	graphH = 120
 
-	maxTrailSegments = 600 // maksymalna liczba segmentów śladu na ciało (ograniczenie wydajnościowe)
+	// oś czasu historii (przewijanie)
+	timelineX = 600
+	timelineY = screenHeight - 30
+	timelineW = screenWidth - 400 - timelineX
+	timelineH = 12
+
+	maxTrailSegments = 600  // maksymalna liczba segmentów śladu na ciało (ograniczenie wydajnościowe)
+	maxSubsteps      = 4096 // maksymalna liczba kroków symulacji na klatkę
+
+	// dziennik zdarzeń
+	eventLogMax   = 200 // liczba przechowywanych wpisów
+	eventLogLines = 10  // liczba wyświetlanych wpisów
 )
 
 // TrailSegment ---
@@ -50,21 +72,21 @@ type TrailSegment struct {
 
 // Game ---
 type Game struct {
-	sim     *simulation.Simulator
-	trails  [][]TrailSegment
-	lastPos []physics.Vec2
+	// symulacja liczona w osobnym wątku i jej ostatnia migawka (z niej korzystają Update i Draw)
+	engine *engine.Engine
+	snap   *engine.Snapshot
+	// ślady i ostatnie pozycje według Body.ID - ślad usuniętego ciała wygasa sam
+	trails  map[string][]TrailSegment
+	lastPos map[string]physics.Vec2
 	paused  bool
 
-	selA int
-	selB int
-
-	showComponents  bool
-	forceHistory    []float64
-	forceHistoryMax int
+	// zaznaczone ciała (Body.ID, "" = brak)
+	selA string
+	selB string
 
-	// historie komponentów siły
-	fxHistory []float64
-	fyHistory []float64
+	showComponents bool
+	// historia siły i jej komponentów między zaznaczonymi ciałami
+	force *forceGraph
 
 	// Add mode: narzędzie dodawania nowych ciał
 	addMode   bool    // czy jesteśmy w trybie dodawania
@@ -76,27 +98,123 @@ type Game struct {
 	// widoczność panelu skrótów
 	shortcutsVisible bool
 
-	// ścieżka do oryginalnego pliku konfiguracyjnego (do resetu)
-	initialConfigPath string
+	// scena, z której wczytano symulację (do resetu)
+	scene assets.Scene
+	// tempo symulacji: substeps kroków na klatkę albo (realtime) simRate jednostek czasu na sekundę
+	substeps int
+	realtime bool
+	simRate  float64
+	stepDebt float64
+
+	// historia edycji (Ctrl+Z / Ctrl+Y)
+	edits simulation.EditHistory
+
+	// ścieżka pliku stanu (Ctrl+S zapis, Ctrl+O odczyt)
+	checkpointPath string
+
+	// zapis trajektorii (przycisk Rec): format pliku, odstęp w krokach i bieżący zapis
+	recordFormat trajectory.Format
+	recordEvery  int
+	recorder     *trajectory.Recorder
+	recordStop   func() error // odłącza recorder od symulatora (zamyka plik)
+	recordPath   string
+
+	// format eksportu migawek (Ctrl+E)
+	exportFormat export.Format
+
+	// historia do przewijania: limit pamięci w bajtach (0 = wyłączona) i odstęp klatek
+	historyBudget int
+	keyframeEvery int
 
 	// czy modal potwierdzenia resetu jest otwarty
 	resetModalOpen bool
+
+	// obserwowanie pliku sceny (nil = wyłączone) i modal z propozycją ponownego wczytania
+	watcher         *assets.Watcher
+	reloadModalOpen bool
+
+	// dziennik zdarzeń symulacji (kolizje, ucieczki, apsydy...)
+	eventLog         []string
+	eventLogVisible  bool
+	eventLogUnsubscr func()
+
+	// obsługa zdarzeń zgłoszonych w wątku symulacji, wykonywana w Update (patrz deferred)
+	inboxMu sync.Mutex
+	inbox   []func()
+
+	// sterowanie przez lokalne API HTTP (nil = wyłączone)
+	api *apiControl
 }
 
 // Update ---
 func (g *Game) Update() error {
+	g.refresh()
+	defer g.api.share()
+
+	// zmiana pliku sceny na dysku: zaproponuj ponowne wczytanie
+	if g.watcher != nil && !g.modalOpen() && g.watcher.Poll(time.Now()) {
+		g.reloadModalOpen = true
+		g.notify(fmt.Sprintf("plik sceny %s zmienił się", g.scene))
+	}
+
 	// klawisze
 	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
-		g.paused = !g.paused
+		g.togglePause()
 	}
 	if inpututil.IsKeyJustPressed(ebiten.KeyN) && g.paused {
-		g.advanceOneStep()
+		g.advance(1)
+	}
+	// tempo symulacji: [ / ] wolniej / szybciej, \ przełącza tryb kroków na klatkę / czasu rzeczywistego
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketRight) {
+		g.changeSpeed(2)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBracketLeft) {
+		g.changeSpeed(0.5)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackslash) {
+		g.realtime = !g.realtime
+		g.stepDebt = 0
 	}
 
 	// Toggle shortcuts visibility
 	if inpututil.IsKeyJustPressed(ebiten.KeyH) {
 		g.shortcutsVisible = !g.shortcutsVisible
 	}
+	// zapis / odczyt stanu symulacji
+	if ctrlPressed() && inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		if err := g.engine.Call(func(sim *simulation.Simulator) error { return sim.Save(g.checkpointPath) }); err != nil {
+			log.Printf("Checkpoint save failed: %v", err)
+		} else {
+			g.notify(fmt.Sprintf("zapisano stan do %s", g.checkpointPath))
+		}
+	}
+	if ctrlPressed() && inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		if sim, err := simulation.Restore(g.checkpointPath); err != nil {
+			log.Printf("Checkpoint restore failed: %v", err)
+		} else {
+			g.setSimulator(sim)
+			g.notify(fmt.Sprintf("wczytano stan z %s (krok %d)", g.checkpointPath, g.snap.Step))
+		}
+	}
+
+	// eksport migawki dla ParaView / OVITO
+	if ctrlPressed() && inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		var path string
+		err := g.engine.Call(func(sim *simulation.Simulator) error {
+			path = fmt.Sprintf("snapshot_%08d.%s", sim.Step, g.exportFormat)
+			return export.Snapshot(path, g.exportFormat, sim)
+		})
+		if err != nil {
+			log.Printf("Export failed: %v", err)
+		} else {
+			g.notify(fmt.Sprintf("wyeksportowano migawkę do %s", path))
+		}
+	}
+
+	// dziennik zdarzeń
+	if !ctrlPressed() && inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		g.setEventLogVisible(!g.eventLogVisible)
+	}
 
 	// przełączniki w trybie Add (L - locked, V - anti)
 	if g.addMode {
@@ -108,35 +226,55 @@ func (g *Game) Update() error {
 		}
 	} else {
 		// gdy nie w trybie add: pozwól na togglowanie Locked/Anti dla wybranego ciała (selA)
-		if inpututil.IsKeyJustPressed(ebiten.KeyL) && g.selA != -1 {
-			g.sim.Bodies[g.selA].Locked = !g.sim.Bodies[g.selA].Locked
-			if g.sim.Bodies[g.selA].Locked {
-				g.sim.Bodies[g.selA].ColorC = color.RGBA{200, 200, 200, 255}
-			} else {
-				g.sim.Bodies[g.selA].ColorC = color.RGBA{200, 200, 255, 255}
-			}
+		if inpututil.IsKeyJustPressed(ebiten.KeyL) && g.selA != "" {
+			g.toggleLocked()
 		}
-		if inpututil.IsKeyJustPressed(ebiten.KeyV) && g.selA != -1 {
-			g.sim.Bodies[g.selA].Anti = !g.sim.Bodies[g.selA].Anti
-			if g.sim.Bodies[g.selA].Anti {
-				g.sim.Bodies[g.selA].ColorC = color.RGBA{255, 120, 120, 255}
-			} else {
-				g.sim.Bodies[g.selA].ColorC = color.RGBA{200, 200, 255, 255}
-			}
+		if inpututil.IsKeyJustPressed(ebiten.KeyV) && g.selA != "" {
+			g.toggleAnti()
 		}
 		// klawisze do zmiany masy/promienia dla selA
-		if g.selA != -1 {
+		if g.selA != "" {
 			if inpututil.IsKeyJustPressed(ebiten.KeyEqual) || inpututil.IsKeyJustPressed(ebiten.KeyK) { // = or K increase mass
-				g.sim.Bodies[g.selA].Mass *= 1.1
+				g.scaleMass(1.1)
 			}
 			if inpututil.IsKeyJustPressed(ebiten.KeyMinus) || inpututil.IsKeyJustPressed(ebiten.KeyJ) { // - or J decrease mass
-				g.sim.Bodies[g.selA].Mass *= 0.9
+				g.scaleMass(0.9)
 			}
 			if inpututil.IsKeyJustPressed(ebiten.KeyR) { // R increase radius
-				g.sim.Bodies[g.selA].Radius *= 1.1
+				g.scaleRadius(1.1)
 			}
 			if inpututil.IsKeyJustPressed(ebiten.KeyT) { // T decrease radius
-				g.sim.Bodies[g.selA].Radius *= 0.9
+				g.scaleRadius(0.9)
+			}
+		}
+	}
+
+	// cofanie / ponawianie edycji
+	if ctrlPressed() && !g.modalOpen() {
+		if inpututil.IsKeyJustPressed(ebiten.KeyZ) && !ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.undo()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyY) || (inpututil.IsKeyJustPressed(ebiten.KeyZ) && ebiten.IsKeyPressed(ebiten.KeyShift)) {
+			g.redo()
+		}
+	}
+
+	// przewijanie historii w pauzie: strzałki o krok wstecz / w przód, przeciąganie po osi czasu
+	if g.paused && g.snap.History != nil && !g.modalOpen() {
+		if keyRepeat(ebiten.KeyArrowLeft) {
+			g.seek(g.snap.Step - 1)
+		}
+		if keyRepeat(ebiten.KeyArrowRight) {
+			g.seek(g.snap.Step + 1)
+		}
+		if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+			mx, my := ebiten.CursorPosition()
+			if pointInRect(mx, my, timelineX, timelineY-4, timelineW, timelineH+8) {
+				first, last := g.timelineRange()
+				frac := float64(mx-timelineX) / float64(timelineW)
+				if target := first + int64(math.Round(frac*float64(last-first))); target != g.snap.Step {
+					g.seek(target)
+				}
 			}
 		}
 	}
@@ -166,9 +304,11 @@ func (g *Game) Update() error {
 		radPlusY := massPlusY
 		radMinusX := radPlusX - uiBtnPad - smallBtnW
 		radMinusY := massPlusY
+		recX := radMinusX - uiBtnPad - uiBtnW
+		recY := uiBtnPad
 
 		// Jeśli modal potwierdzenia jest otwarty: obsłuż tylko modal
-		if g.resetModalOpen {
+		if g.modalOpen() {
 			mw := 360
 			mh := 120
 			mx0 := (screenWidth - mw) / 2
@@ -178,41 +318,48 @@ func (g *Game) Update() error {
 			noX := mx0 + mw - 40 - uiBtnW
 			noY := yesY
 			if pointInRect(mx, my, yesX, yesY, uiBtnW, uiBtnH) {
-				// potwierdz reset
-				if err := g.resetSimulation(); err != nil {
-					log.Printf("Reset failed: %v", err)
-				}
+				// potwierdz reset / ponowne wczytanie
+				g.confirmModal()
 				return nil
 			}
 			if pointInRect(mx, my, noX, noY, uiBtnW, uiBtnH) {
 				// anuluj modal
-				g.resetModalOpen = false
+				g.closeModal()
 				return nil
 			}
 			// klik poza modal zamyka modal
-			g.resetModalOpen = false
+			g.closeModal()
+			return nil
+		}
+
+		// oś czasu obsługujemy wyżej (przeciąganie)
+		if g.snap.History != nil && pointInRect(mx, my, timelineX, timelineY-4, timelineW, timelineH+8) {
 			return nil
 		}
 
 		// obsłuż small buttons (założenie: działają tylko gdy jest zaznaczone selA)
-		if pointInRect(mx, my, massPlusX, massPlusY, smallBtnW, smallBtnH) && g.selA != -1 {
-			g.sim.Bodies[g.selA].Mass *= 1.1
+		if pointInRect(mx, my, massPlusX, massPlusY, smallBtnW, smallBtnH) && g.selA != "" {
+			g.scaleMass(1.1)
 			return nil
 		}
-		if pointInRect(mx, my, massMinusX, massMinusY, smallBtnW, smallBtnH) && g.selA != -1 {
-			g.sim.Bodies[g.selA].Mass *= 0.9
+		if pointInRect(mx, my, massMinusX, massMinusY, smallBtnW, smallBtnH) && g.selA != "" {
+			g.scaleMass(0.9)
 			return nil
 		}
-		if pointInRect(mx, my, radPlusX, radPlusY, smallBtnW, smallBtnH) && g.selA != -1 {
-			g.sim.Bodies[g.selA].Radius *= 1.1
+		if pointInRect(mx, my, radPlusX, radPlusY, smallBtnW, smallBtnH) && g.selA != "" {
+			g.scaleRadius(1.1)
 			return nil
 		}
-		if pointInRect(mx, my, radMinusX, radMinusY, smallBtnW, smallBtnH) && g.selA != -1 {
-			g.sim.Bodies[g.selA].Radius *= 0.9
+		if pointInRect(mx, my, radMinusX, radMinusY, smallBtnW, smallBtnH) && g.selA != "" {
+			g.scaleRadius(0.9)
 			return nil
 		}
 
-		// obsłuż UI (Add/Comp/Quit/Step/Pause/Reset)
+		// obsłuż UI (Rec/Add/Comp/Quit/Step/Pause/Reset)
+		if pointInRect(mx, my, recX, recY, uiBtnW, uiBtnH) {
+			g.toggleRecording()
+			return nil
+		}
 		if pointInRect(mx, my, addX, addY, uiBtnW, uiBtnH) {
 			g.addMode = !g.addMode
 			if g.addMode {
@@ -224,7 +371,7 @@ func (g *Game) Update() error {
 			return nil
 		}
 		if pointInRect(mx, my, compX, compY, uiBtnW, uiBtnH) {
-			if g.selA != -1 && g.selB != -1 {
+			if g.selA != "" && g.selB != "" {
 				g.showComponents = !g.showComponents
 			}
 			return nil
@@ -235,17 +382,17 @@ func (g *Game) Update() error {
 			return nil
 		}
 		if pointInRect(mx, my, quitX, quitY, uiBtnW, uiBtnH) {
-			os.Exit(0)
-			return nil
+			// RunGame kończy się bez błędu; porządki (zapis trajektorii) robi main
+			return ebiten.Termination
 		}
 		if pointInRect(mx, my, stepX, stepY, uiBtnW, uiBtnH) {
 			if g.paused {
-				g.advanceOneStep()
+				g.advance(1)
 			}
 			return nil
 		}
 		if pointInRect(mx, my, pauseX, pauseY, uiBtnW, uiBtnH) {
-			g.paused = !g.paused
+			g.togglePause()
 			return nil
 		}
 
@@ -259,8 +406,8 @@ func (g *Game) Update() error {
 				nb := physics.Body{
 					Mass:   g.addMass,
 					Pos:    pos,
-					Vel:    physics.Vec2{0, 0},
-					Acc:    physics.Vec2{0, 0},
+					Vel:    physics.Vec2{X: 0, Y: 0},
+					Acc:    physics.Vec2{X: 0, Y: 0},
 					Radius: g.addRadius,
 					ColorC: color.RGBA{200, 200, 255, 255},
 					Locked: g.addLocked,
@@ -272,10 +419,8 @@ func (g *Game) Update() error {
 				} else if nb.Locked {
 					nb.ColorC = color.RGBA{200, 200, 200, 255}
 				}
-				// dodaj do symulacji i pomocniczych tablic
-				g.sim.Bodies = append(g.sim.Bodies, nb)
-				g.lastPos = append(g.lastPos, nb.Pos)
-				g.trails = append(g.trails, []TrailSegment{})
+				// dodaj do symulacji (tablice pomocnicze uzupełnia afterEdit)
+				g.do(&simulation.AddBodyCmd{Body: nb})
 				// po dodaniu pozostajemy w trybie add (aby dodać kolejne) — chyba że chcesz inaczej
 			}
 			return nil
@@ -283,55 +428,51 @@ func (g *Game) Update() error {
 
 		// normalne kliknięcie wyboru ciała (istniejąca logika)
 		mouse := physics.Vec2{X: float64(mx) - float64(screenWidth)/2, Y: float64(my) - float64(screenHeight)/2}
-		clicked := -1
+		clicked := ""
 		minD := 1e18
-		for i := range g.sim.Bodies {
-			b := &g.sim.Bodies[i]
+		for i := range g.snap.Bodies {
+			b := &g.snap.Bodies[i]
 			d := b.Pos.Sub(mouse).Len()
 			if d <= b.Radius && d < minD {
-				clicked = i
+				clicked = b.ID
 				minD = d
 			}
 		}
-		if clicked >= 0 {
+		if clicked != "" {
 			prevA, prevB := g.selA, g.selB
-			if g.selA == -1 {
+			if g.selA == "" {
 				g.selA = clicked
-			} else if g.selB == -1 {
+			} else if g.selB == "" {
 				if clicked == g.selA {
-					g.selA = -1
+					g.selA = ""
 				} else {
 					g.selB = clicked
 				}
 			} else {
 				if clicked == g.selA {
-					g.selA = -1
-					g.selB = -1
+					g.selA = ""
+					g.selB = ""
 				} else if clicked == g.selB {
-					g.selB = -1
+					g.selB = ""
 				} else {
 					g.selA = clicked
-					g.selB = -1
+					g.selB = ""
 				}
 			}
 			if g.selA != prevA || g.selB != prevB {
-				g.forceHistory = nil
-				g.fxHistory = nil
-				g.fyHistory = nil
+				g.force.reset(g.selA, g.selB)
 			}
 		}
 	}
 
 	// klawiszowa obsluga modalu
-	if g.resetModalOpen {
+	if g.modalOpen() {
 		if inpututil.IsKeyJustPressed(ebiten.KeyY) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
-			if err := g.resetSimulation(); err != nil {
-				log.Printf("Reset failed: %v", err)
-			}
+			g.confirmModal()
 			return nil
 		}
 		if inpututil.IsKeyJustPressed(ebiten.KeyN) || inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
-			g.resetModalOpen = false
+			g.closeModal()
 			return nil
 		}
 	}
@@ -340,74 +481,222 @@ func (g *Game) Update() error {
 		return nil
 	}
 
-	g.advanceOneStep()
+	g.advance(g.stepsThisFrame())
 	return nil
 }
 
-// advanceOneStep ---
-func (g *Game) advanceOneStep() {
-	g.sim.Update()
-	// jeśli zaznaczone 2 ciała, oblicz siłę
-	if g.selA != -1 && g.selB != -1 {
-		b1 := g.sim.Bodies[g.selA]
-		b2 := g.sim.Bodies[g.selB]
+// stepsThisFrame zwraca liczbę kroków symulacji do wykonania w bieżącej klatce
+func (g *Game) stepsThisFrame() int {
+	if !g.realtime {
+		return g.substeps
+	}
+	// tryb czasu rzeczywistego: simRate jednostek czasu symulacji na sekundę,
+	// ułamki kroków przechodzą na następne klatki
+	g.stepDebt += g.simRate / float64(ebiten.TPS()) / g.snap.Dt
+	n := int(g.stepDebt)
+	g.stepDebt -= float64(n)
+	if n > maxSubsteps {
+		// nie nadążamy - nie kumulujemy zaległości
+		n = maxSubsteps
+		g.stepDebt = 0
+	}
+	return n
+}
+
+// changeSpeed mnoży tempo symulacji (liczbę kroków na klatkę lub tempo czasu rzeczywistego)
+func (g *Game) changeSpeed(f float64) {
+	if g.realtime {
+		g.simRate *= f
+		return
+	}
+	g.substeps = max(1, min(maxSubsteps, int(float64(g.substeps)*f)))
+}
+
+// toggleRecording włącza lub wyłącza zapis trajektorii do pliku w bieżącym katalogu
+func (g *Game) toggleRecording() {
+	if g.recorder != nil {
+		g.stopRecording()
+		return
+	}
+	path := fmt.Sprintf("trajectory-%s.%s", time.Now().Format("20060102-150405"), g.recordFormat)
+	w, err := trajectory.Create(path, g.recordFormat)
+	if err != nil {
+		g.notify(fmt.Sprintf("nie można rozpocząć zapisu trajektorii: %v", err))
+		return
+	}
+	// recorder jest obserwatorem kroków: zapisuje stan początkowy i potem co recordEvery kroków
+	rec := &trajectory.Recorder{W: w, Every: g.recordEvery}
+	var stop func() error
+	err = g.engine.Call(func(sim *simulation.Simulator) (err error) {
+		stop, err = sim.Attach(rec)
+		return err
+	})
+	if err != nil {
+		w.Close()
+		g.notify(fmt.Sprintf("błąd zapisu trajektorii: %v", err))
+		return
+	}
+	g.recorder = rec
+	g.recordStop = stop
+	g.recordPath = path
+	g.notify(fmt.Sprintf("zapis trajektorii do %s", path))
+}
+
+// stopRecording kończy zapis trajektorii (jeśli trwa)
+func (g *Game) stopRecording() {
+	if g.recorder == nil {
+		return
+	}
+	if err := g.engine.Call(func(*simulation.Simulator) error { return g.recordStop() }); err != nil {
+		g.notify(fmt.Sprintf("błąd zapisu trajektorii: %v", err))
+	} else {
+		g.notify(fmt.Sprintf("zakończono zapis trajektorii do %s", g.recordPath))
+	}
+	g.recorder = nil
+	g.recordStop = nil
+	g.recordPath = ""
+}
+
+// togglePause wstrzymuje lub wznawia symulację; przy wstrzymaniu porzuca kroki zlecone, ale jeszcze niewykonane
+func (g *Game) togglePause() {
+	g.paused = !g.paused
+	if g.paused {
+		g.engine.Cancel()
+	}
+}
+
+// advance zleca wątkowi symulacji n kroków. Gdy symulacja nie nadąża, kolejne kroki są zlecane
+// dopiero po odrobieniu zaległości - tempo spada zamiast narastającego opóźnienia obrazu.
+func (g *Game) advance(n int) {
+	if n <= 0 || g.engine.Pending() >= int64(n) {
+		return
+	}
+	g.engine.Advance(n)
+}
+
+// refresh przyjmuje najnowszą migawkę z wątku symulacji: dopisuje ślady, obsługuje zdarzenia
+// zgłoszone od poprzedniej klatki i błędy obserwatorów
+func (g *Game) refresh() {
+	prev := g.snap
+	g.snap = g.engine.Snapshot()
+	if g.snap.Step > prev.Step {
+		g.updateTrails(g.snap.Time - prev.Time)
+	}
+
+	g.inboxMu.Lock()
+	inbox := g.inbox
+	g.inbox = nil
+	g.inboxMu.Unlock()
+	for _, fn := range inbox {
+		fn()
+	}
+	g.dropMissingSelection()
+
+	if err := g.engine.Err(); err != nil {
+		// obserwator z błędem został już odłączony (i zamknięty)
+		var oe *simulation.ObserverError
+		if errors.As(err, &oe) && oe.Observer == simulation.Observer(g.recorder) {
+			g.notify(fmt.Sprintf("błąd zapisu trajektorii: %v", err))
+			g.recorder = nil
+			g.recordStop = nil
+			g.recordPath = ""
+		} else {
+			g.notify(fmt.Sprintf("błąd obserwatora symulacji: %v", err))
+		}
+	}
+}
+
+// updateTrails dopisuje do śladów ruch od poprzedniej migawki; elapsed - czas symulacji między migawkami
+func (g *Game) updateTrails(elapsed float64) {
+	for i := range g.snap.Bodies {
+		b := g.snap.Bodies[i]
+		last, ok := g.lastPos[b.ID]
+		g.lastPos[b.ID] = b.Pos
+		// w pudle okresowym nie łączymy punktów po zawinięciu przez krawędź
+		if !ok || g.snap.Boundary.Wrapped(last, b.Pos) {
+			continue
+		}
+		g.trails[b.ID] = append(g.trails[b.ID], TrailSegment{
+			X0:    float64(screenWidth)/2 + last.X,
+			Y0:    float64(screenHeight)/2 + last.Y,
+			X1:    float64(screenWidth)/2 + b.Pos.X,
+			Y1:    float64(screenHeight)/2 + b.Pos.Y,
+			Life:  trailMaxLife,
+			Color: b.ColorC,
+		})
+		// ogranicz długość śladu aby nie rysować zbyt wielu segmentów
+		if trail := g.trails[b.ID]; len(trail) > maxTrailSegments {
+			g.trails[b.ID] = trail[len(trail)-maxTrailSegments:]
+		}
+	}
+	// trim by life (także ślady ciał, których już nie ma)
+	for id, trail := range g.trails {
+		newTrail := trail[:0]
+		for j := range trail {
+			trail[j].Life -= elapsed
+			if trail[j].Life > 0 {
+				newTrail = append(newTrail, trail[j])
+			}
+		}
+		if len(newTrail) == 0 && g.snap.Index(id) < 0 {
+			delete(g.trails, id)
+			delete(g.lastPos, id)
+			continue
+		}
+		g.trails[id] = newTrail
+	}
+}
+
+// forceGraph - historia siły między dwoma zaznaczonymi ciałami do wykresów. Próbki dopisuje
+// obserwator kroków w wątku symulacji, a interfejs zmienia parę ciał i czyta próbki, stąd mu.
+type forceGraph struct {
+	mu        sync.Mutex
+	a, b      string // Body.ID zaznaczonych ciał
+	max       int    // liczba pamiętanych próbek
+	f, fx, fy []float64
+}
+
+// reset ustawia parę ciał i czyści historię
+func (fg *forceGraph) reset(a, b string) {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	fg.a, fg.b = a, b
+	fg.f, fg.fx, fg.fy = nil, nil, nil
+}
+
+// record dopisuje do wykresu siłę między zaznaczonymi ciałami; jest obserwatorem kroków symulacji
+func (fg *forceGraph) record(sim *simulation.Simulator, _ float64) error {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	if b1, b2 := sim.Body(fg.a), sim.Body(fg.b); b1 != nil && b2 != nil {
 		dx := b2.Pos.X - b1.Pos.X
 		dy := b2.Pos.Y - b1.Pos.Y
 		d := math.Hypot(dx, dy)
 		eps := 1e-6
-		F := physics.G * b1.Mass * b2.Mass / (d*d + eps)
+		F := sim.G * b1.Mass * b2.Mass / (d*d + eps)
 		// komponenty
 		ux := dx / (d + 1e-12)
 		uy := dy / (d + 1e-12)
 		Fx := F * ux
 		Fy := F * uy
-		g.forceHistory = append(g.forceHistory, F)
-		g.fxHistory = append(g.fxHistory, Fx)
-		g.fyHistory = append(g.fyHistory, Fy)
-		if g.forceHistoryMax == 0 {
-			g.forceHistoryMax = 600
-		}
-		if len(g.forceHistory) > g.forceHistoryMax {
-			start := len(g.forceHistory) - g.forceHistoryMax
-			g.forceHistory = g.forceHistory[start:]
-		}
-		// trim fx/fy to same length
-		if len(g.fxHistory) > g.forceHistoryMax {
-			g.fxHistory = g.fxHistory[len(g.fxHistory)-g.forceHistoryMax:]
-		}
-		if len(g.fyHistory) > g.forceHistoryMax {
-			g.fyHistory = g.fyHistory[len(g.fyHistory)-g.forceHistoryMax:]
+		fg.f = append(fg.f, F)
+		fg.fx = append(fg.fx, Fx)
+		fg.fy = append(fg.fy, Fy)
+		if len(fg.f) > fg.max {
+			start := len(fg.f) - fg.max
+			fg.f = fg.f[start:]
+			fg.fx = fg.fx[start:]
+			fg.fy = fg.fy[start:]
 		}
 	}
+	return nil
+}
 
-	// update śladów
-	for i := range g.sim.Bodies {
-		b := g.sim.Bodies[i]
-		seg := TrailSegment{
-			X0:    float64(screenWidth)/2 + g.lastPos[i].X,
-			Y0:    float64(screenHeight)/2 + g.lastPos[i].Y,
-			X1:    float64(screenWidth)/2 + b.Pos.X,
-			Y1:    float64(screenHeight)/2 + b.Pos.Y,
-			Life:  trailMaxLife,
-			Color: b.ColorC,
-		}
-		g.trails[i] = append(g.trails[i], seg)
-		// ogranicz długość śladu aby nie rysować zbyt wielu segmentów
-		if len(g.trails[i]) > maxTrailSegments {
-			start := len(g.trails[i]) - maxTrailSegments
-			g.trails[i] = g.trails[i][start:]
-		}
-		g.lastPos[i] = b.Pos
-		// trim by life
-		newTrail := g.trails[i][:0]
-		for j := range g.trails[i] {
-			g.trails[i][j].Life -= g.sim.Dt
-			if g.trails[i][j].Life > 0 {
-				newTrail = append(newTrail, g.trails[i][j])
-			}
-		}
-		g.trails[i] = newTrail
-	}
+// samples zwraca kopie historii F, Fx i Fy
+func (fg *forceGraph) samples() (f, fx, fy []float64) {
+	fg.mu.Lock()
+	defer fg.mu.Unlock()
+	return append([]float64(nil), fg.f...), append([]float64(nil), fg.fx...), append([]float64(nil), fg.fy...)
 }
 
 // helpers for Wu (missing definitions)
@@ -633,8 +922,30 @@ func drawCircle(screen *ebiten.Image, cx, cy, r float64, clr color.RGBA) {
 	}
 }
 
+// drawBoundary rysuje pudło warunków brzegowych (ściany odbijające ciągłą linią, okresowe przerywaną)
+func drawBoundary(screen *ebiten.Image, bd *physics.Boundary) {
+	x0 := float64(screenWidth)/2 + bd.Min.X
+	y0 := float64(screenHeight)/2 + bd.Min.Y
+	x1 := float64(screenWidth)/2 + bd.Max.X
+	y1 := float64(screenHeight)/2 + bd.Max.Y
+	clr := color.RGBA{120, 160, 220, 200}
+	edges := [][4]float64{{x0, y0, x1, y0}, {x1, y0, x1, y1}, {x1, y1, x0, y1}, {x0, y1, x0, y0}}
+	for _, e := range edges {
+		if bd.Kind != physics.Periodic {
+			drawLine(screen, e[0], e[1], e[2], e[3], clr)
+			continue
+		}
+		// linia przerywana: kreski po 8 px co 16 px
+		l := math.Hypot(e[2]-e[0], e[3]-e[1])
+		for t := 0.0; t < l; t += 16 {
+			t1 := math.Min(t+8, l)
+			drawLine(screen, e[0]+(e[2]-e[0])*t/l, e[1]+(e[3]-e[1])*t/l, e[0]+(e[2]-e[0])*t1/l, e[1]+(e[3]-e[1])*t1/l, clr)
+		}
+	}
+}
+
 // drawForceGraph rysuje wykres z autoskalowaniem Y i etykietą (w prostszej formie)
-func drawForceGraph(screen *ebiten.Image, data []float64, x, y, w, h int, lineColor color.RGBA, title string) {
+func drawForceGraph(screen *ebiten.Image, data []float64, x, y, w, h int, lineColor color.RGBA, title, unit string) {
 	// tło
 	bg := ebiten.NewImage(w, h)
 	bg.Fill(color.RGBA{8, 8, 16, 200})
@@ -713,10 +1024,18 @@ func drawForceGraph(screen *ebiten.Image, data []float64, x, y, w, h int, lineCo
 			py = ny
 		}
 	}
-	lbl := fmt.Sprintf("%.3e..%.3e", minV, maxV)
+	lbl := fmt.Sprintf("%.3e..%.3e%s", minV, maxV, unit)
 	text.Draw(screen, lbl, basicfont.Face7x13, x+6, y+h-6, color.RGBA{180, 180, 200, 180})
 }
 
+// unit zwraca etykietę jednostki wielkości d w układzie sceny (" AU/day"), pustą dla jednostek umownych
+func (g *Game) unit(d units.Dim) string {
+	if l := g.snap.Units.Label(d); l != "" {
+		return " " + l
+	}
+	return ""
+}
+
 // Draw ---
 func (g *Game) Draw(screen *ebiten.Image) {
 	// trails
@@ -730,13 +1049,17 @@ func (g *Game) Draw(screen *ebiten.Image) {
 			drawSmoothSegment(screen, s.X0, s.Y0, s.X1, s.Y1, s.Color)
 		}
 	}
+	// granice obszaru symulacji
+	if g.snap.Boundary != nil {
+		drawBoundary(screen, g.snap.Boundary)
+	}
 	// bodies
-	for i := range g.sim.Bodies {
-		b := g.sim.Bodies[i]
+	for i := range g.snap.Bodies {
+		b := g.snap.Bodies[i]
 		x := float64(screenWidth)/2 + b.Pos.X
 		y := float64(screenHeight)/2 + b.Pos.Y
 		drawCircle(screen, x, y, b.Radius, b.ColorC)
-		if i == g.selA || i == g.selB {
+		if b.ID == g.selA || b.ID == g.selB {
 			drawCircle(screen, x, y, b.Radius+3, color.RGBA{255, 255, 255, 180})
 		}
 		// ikony Locked / Anti - małe symbole obok ciała
@@ -765,7 +1088,11 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	}
 
 	// UI
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("Env: %s\nPaused: %v", g.sim.Name, g.paused))
+	speed := fmt.Sprintf("x%d (steps/frame)", g.substeps)
+	if g.realtime {
+		speed = fmt.Sprintf("%.3g t/s (real time)", g.simRate)
+	}
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("Env: %s\nPaused: %v\nt = %.2f%s  step = %d\nSpeed: %s", g.snap.Name, g.paused, g.snap.Time, g.unit(units.Time), g.snap.Step, speed))
 	drawShortcuts(screen, g)
 	// rysowanie przycisków w prawym górnym rogu (dopisz Add)
 	pauseX := screenWidth - uiBtnPad - uiBtnW
@@ -787,6 +1114,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	radPlusY := massPlusY
 	radMinusX := radPlusX - uiBtnPad - smallBtnW
 	radMinusY := massPlusY
+	recX := radMinusX - uiBtnPad - uiBtnW
+	recY := uiBtnPad
 
 	// wykryj, czy kursor jest nad którymś przyciskiem
 	mx, my := ebiten.CursorPosition()
@@ -796,7 +1125,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	hoverStep := pointInRect(mx, my, stepX, stepY, uiBtnW, uiBtnH)
 	hoverPause := pointInRect(mx, my, pauseX, pauseY, uiBtnW, uiBtnH)
 	hoverReset := pointInRect(mx, my, resetX, addY, uiBtnW, uiBtnH)
-	compDisabled := !(g.selA != -1 && g.selB != -1)
+	compDisabled := !(g.selA != "" && g.selB != "")
 	drawButton(screen, addX, addY, uiBtnW, uiBtnH, "Add", g.addMode, false, hoverAdd)
 	drawButton(screen, compX, compY, uiBtnW, uiBtnH, "Comp", g.showComponents, compDisabled, hoverComp)
 	drawButton(screen, quitX, quitY, uiBtnW, uiBtnH, "Quit", false, false, hoverQuit)
@@ -807,12 +1136,17 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	}
 	drawButton(screen, pauseX, pauseY, uiBtnW, uiBtnH, pauseLabel, g.paused, false, hoverPause)
 	drawButton(screen, resetX, addY, uiBtnW, uiBtnH, "Reset", false, false, hoverReset)
+	recLabel := "Rec"
+	if g.recorder != nil {
+		recLabel = "Stop rec"
+	}
+	drawButton(screen, recX, recY, uiBtnW, uiBtnH, recLabel, g.recorder != nil, false, pointInRect(mx, my, recX, recY, uiBtnW, uiBtnH))
 
 	// rysuj small buttons (działają tylko dla zaznaczonego selA)
-	drawButton(screen, massPlusX, massPlusY, smallBtnW, smallBtnH, "M+", false, g.selA == -1, pointInRect(mx, my, massPlusX, massPlusY, smallBtnW, smallBtnH))
-	drawButton(screen, massMinusX, massMinusY, smallBtnW, smallBtnH, "M-", false, g.selA == -1, pointInRect(mx, my, massMinusX, massMinusY, smallBtnW, smallBtnH))
-	drawButton(screen, radPlusX, radPlusY, smallBtnW, smallBtnH, "R+", false, g.selA == -1, pointInRect(mx, my, radPlusX, radPlusY, smallBtnW, smallBtnH))
-	drawButton(screen, radMinusX, radMinusY, smallBtnW, smallBtnH, "R-", false, g.selA == -1, pointInRect(mx, my, radMinusX, radMinusY, smallBtnW, smallBtnH))
+	drawButton(screen, massPlusX, massPlusY, smallBtnW, smallBtnH, "M+", false, g.selA == "", pointInRect(mx, my, massPlusX, massPlusY, smallBtnW, smallBtnH))
+	drawButton(screen, massMinusX, massMinusY, smallBtnW, smallBtnH, "M-", false, g.selA == "", pointInRect(mx, my, massMinusX, massMinusY, smallBtnW, smallBtnH))
+	drawButton(screen, radPlusX, radPlusY, smallBtnW, smallBtnH, "R+", false, g.selA == "", pointInRect(mx, my, radPlusX, radPlusY, smallBtnW, smallBtnH))
+	drawButton(screen, radMinusX, radMinusY, smallBtnW, smallBtnH, "R-", false, g.selA == "", pointInRect(mx, my, radMinusX, radMinusY, smallBtnW, smallBtnH))
 
 	// jeśli w trybie Add - pokaż podgląd pozycji i ustawienia
 	if g.addMode {
@@ -839,9 +1173,7 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	}
 
 	// arrow + force + graph
-	if g.selA != -1 && g.selB != -1 {
-		b1 := g.sim.Bodies[g.selA]
-		b2 := g.sim.Bodies[g.selB]
+	if b1, b2 := g.snap.Body(g.selA), g.snap.Body(g.selB); b1 != nil && b2 != nil {
 		x1 := float64(screenWidth)/2 + b1.Pos.X
 		y1 := float64(screenHeight)/2 + b1.Pos.Y
 		x2 := float64(screenWidth)/2 + b2.Pos.X
@@ -854,26 +1186,27 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		dy := b2.Pos.Y - b1.Pos.Y
 		dist := math.Hypot(dx, dy)
 		eps := 1e-6
-		force := physics.G * b1.Mass * b2.Mass / (dist*dist + eps)
+		force := g.snap.G * b1.Mass * b2.Mass / (dist*dist + eps)
 		midX := (x1 + x2) / 2
 		midY := (y1 + y2) / 2
-		label := fmt.Sprintf("F = %.3e", force)
+		label := fmt.Sprintf("F = %.3e%s", force, g.unit(units.Force))
 		text.Draw(screen, label, basicfont.Face7x13, int(midX)-len(label)*4, int(midY)-6, color.RGBA{255, 255, 200, 255})
 
 		// jeśli komponenty włączone - wyświetl Fx/Fy i osobne wykresy
 		graphX := screenWidth - graphW - 16
 		baseY := screenHeight - graphH - 16
 		step := graphH + 8
+		fHistory, fxHistory, fyHistory := g.force.samples()
 		if g.showComponents {
 			// Fx (top)
-			drawForceGraph(screen, g.fxHistory, graphX, baseY-step*2, graphW, graphH, color.RGBA{255, 100, 100, 255}, "Fx")
+			drawForceGraph(screen, fxHistory, graphX, baseY-step*2, graphW, graphH, color.RGBA{255, 100, 100, 255}, "Fx", g.unit(units.Force))
 			// Fy (middle)
-			drawForceGraph(screen, g.fyHistory, graphX, baseY-step, graphW, graphH, color.RGBA{100, 255, 100, 255}, "Fy")
+			drawForceGraph(screen, fyHistory, graphX, baseY-step, graphW, graphH, color.RGBA{100, 255, 100, 255}, "Fy", g.unit(units.Force))
 			// F (bottom)
-			drawForceGraph(screen, g.forceHistory, graphX, baseY, graphW, graphH, color.RGBA{100, 100, 255, 255}, "F")
+			drawForceGraph(screen, fHistory, graphX, baseY, graphW, graphH, color.RGBA{100, 100, 255, 255}, "F", g.unit(units.Force))
 		} else {
 			// tylko F
-			drawForceGraph(screen, g.forceHistory, graphX, baseY, graphW, graphH, color.RGBA{100, 100, 255, 255}, "")
+			drawForceGraph(screen, fHistory, graphX, baseY, graphW, graphH, color.RGBA{100, 100, 255, 255}, "", g.unit(units.Force))
 		}
 	}
 
@@ -883,8 +1216,8 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		mouse := physics.Vec2{X: float64(mx) - float64(screenWidth)/2, Y: float64(my) - float64(screenHeight)/2}
 		var hovered *physics.Body
 		minD := 1e18
-		for i := range g.sim.Bodies {
-			b := &g.sim.Bodies[i]
+		for i := range g.snap.Bodies {
+			b := &g.snap.Bodies[i]
 			d := b.Pos.Sub(mouse).Len()
 			if d <= b.Radius && d < minD {
 				hovered = b
@@ -892,13 +1225,20 @@ func (g *Game) Draw(screen *ebiten.Image) {
 			}
 		}
 		if hovered != nil {
-			lines := []string{
-				fmt.Sprintf("Mass: %.3e", hovered.Mass),
-				fmt.Sprintf("Pos: (%.2f, %.2f)", hovered.Pos.X, hovered.Pos.Y),
-				fmt.Sprintf("Vel: (%.2f, %.2f)", hovered.Vel.X, hovered.Vel.Y),
-				fmt.Sprintf("Speed: %.2f", hovered.Vel.Len()),
-				fmt.Sprintf("Radius: %.2f", hovered.Radius),
+			var lines []string
+			if hovered.Name != "" {
+				lines = append(lines, hovered.Name)
+			}
+			if len(hovered.Tags) > 0 {
+				lines = append(lines, "Tags: "+strings.Join(hovered.Tags, ", "))
 			}
+			lines = append(lines,
+				fmt.Sprintf("Mass: %.3e%s", hovered.Mass, g.unit(units.Mass)),
+				fmt.Sprintf("Pos: (%.2f, %.2f)%s", hovered.Pos.X, hovered.Pos.Y, g.unit(units.Length)),
+				fmt.Sprintf("Vel: (%.2f, %.2f)%s", hovered.Vel.X, hovered.Vel.Y, g.unit(units.Velocity)),
+				fmt.Sprintf("Speed: %.2f%s", hovered.Vel.Len(), g.unit(units.Velocity)),
+				fmt.Sprintf("Radius: %.2f%s", hovered.Radius, g.unit(units.Length)),
+			)
 			pad := 6
 			charW := 7
 			lineH := 13
@@ -936,9 +1276,19 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		}
 	}
 
-	// rysuj modal potwierdzenia resetu, jeśli otwarty
+	if g.eventLogVisible {
+		drawEventLog(screen, g.eventLog)
+	}
+	if g.snap.History != nil {
+		first, last := g.timelineRange()
+		drawTimeline(screen, first, last, g.snap.Step, g.snap.Time, g.paused)
+	}
+
+	// rysuj modal potwierdzenia resetu lub ponownego wczytania, jeśli otwarty
 	if g.resetModalOpen {
-		drawResetModal(screen)
+		drawConfirmModal(screen, "Reset simulation?", "Reload initial config and remove added bodies.")
+	} else if g.reloadModalOpen {
+		drawConfirmModal(screen, "Scene file changed. Reload?", "Keeps pause, selection and view settings.")
 	}
 }
 
@@ -962,12 +1312,19 @@ func drawShortcuts(screen *ebiten.Image, g *Game) {
 		lines = append(lines, "GLOBAL")
 		lines = append(lines, "P - Pause/Resume")
 		lines = append(lines, "N - Step (when paused)")
+		lines = append(lines, "Left / Right - scrub history (paused)")
+		lines = append(lines, "[ / ] - slower / faster")
+		lines = append(lines, "\\ - steps/frame <-> real time")
 		lines = append(lines, "L - toggle Locked (selected)")
 		lines = append(lines, "V - toggle Anti (selected)")
 		lines = append(lines, "K / =  - mass + (selected)")
 		lines = append(lines, "J / -  - mass - (selected)")
 		lines = append(lines, "R - radius + (selected)")
 		lines = append(lines, "T - radius - (selected)")
+		lines = append(lines, "E - event log")
+		lines = append(lines, "Ctrl+Z / Ctrl+Y - undo / redo edit")
+		lines = append(lines, "Ctrl+S / Ctrl+O - save / load state")
+		lines = append(lines, "Ctrl+E - export snapshot (VTK/XYZ)")
 		lines = append(lines, "H - hide shortcuts")
 	}
 
@@ -1013,81 +1370,485 @@ func drawShortcuts(screen *ebiten.Image, g *Game) {
 	screen.DrawImage(panel, op)
 }
 
+// drawEventLog rysuje ostatnie wpisy dziennika zdarzeń w lewym dolnym rogu
+func drawEventLog(screen *ebiten.Image, log []string) {
+	pad := 6
+	lineH := 14
+	w := 560
+	h := (eventLogLines+1)*lineH + pad*2
+	panel := ebiten.NewImage(w, h)
+	panel.Fill(color.RGBA{10, 10, 20, 200})
+	inner := ebiten.NewImage(w-2, h-2)
+	inner.Fill(color.RGBA{30, 30, 40, 80})
+	opInner := &ebiten.DrawImageOptions{}
+	opInner.GeoM.Translate(1, 1)
+	panel.DrawImage(inner, opInner)
+
+	text.Draw(panel, "Events (E - hide)", basicfont.Face7x13, pad, pad+lineH-2, color.RGBA{180, 180, 200, 255})
+	start := 0
+	if len(log) > eventLogLines {
+		start = len(log) - eventLogLines
+	}
+	for i, l := range log[start:] {
+		text.Draw(panel, l, basicfont.Face7x13, pad, pad+(i+2)*lineH-2, color.RGBA{220, 220, 220, 255})
+	}
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(12), float64(screenHeight-h-12))
+	screen.DrawImage(panel, op)
+}
+
+// drawTimeline rysuje oś czasu historii z zaznaczeniem bieżącego kroku
+func drawTimeline(screen *ebiten.Image, first, last, step int64, t float64, paused bool) {
+	bar := ebiten.NewImage(timelineW, timelineH)
+	bar.Fill(color.RGBA{30, 30, 40, 200})
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(timelineX), float64(timelineY))
+	screen.DrawImage(bar, op)
+
+	frac := 1.0
+	if last > first {
+		frac = float64(step-first) / float64(last-first)
+	}
+	if w := int(frac * float64(timelineW)); w > 0 {
+		done := ebiten.NewImage(w, timelineH)
+		done.Fill(color.RGBA{70, 110, 170, 200})
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(timelineX), float64(timelineY))
+		screen.DrawImage(done, op)
+	}
+	mx := float64(timelineX) + frac*float64(timelineW)
+	drawLine(screen, mx, float64(timelineY-4), mx, float64(timelineY+timelineH+4), color.RGBA{255, 255, 255, 230})
+
+	label := fmt.Sprintf("history: step %d..%d  now %d (t=%.2f)", first, last, step, t)
+	if paused {
+		label += "  - drag or Left/Right to scrub"
+	}
+	text.Draw(screen, label, basicfont.Face7x13, timelineX, timelineY-8, color.RGBA{200, 200, 220, 220})
+}
+
 func (g *Game) Layout(_, _ int) (int, int) {
 	return screenWidth, screenHeight
 }
 
-// resetSimulation przeładowuje konfigurację z initialConfigPath i resetuje stan gry
+// resetSimulation przeładowuje scenę g.scene i resetuje stan gry
 func (g *Game) resetSimulation() error {
-	if g.initialConfigPath == "" {
-		return fmt.Errorf("no initial config path set")
+	if g.scene.Path == "" {
+		return fmt.Errorf("no initial scene set")
 	}
-	sim, err := simulation.LoadConfig(g.initialConfigPath)
+	sim, err := g.scene.Load()
 	if err != nil {
 		return err
 	}
 	// apply loaded simulator
-	g.sim = sim
-	// reinit helper arrays
-	g.lastPos = make([]physics.Vec2, len(g.sim.Bodies))
-	g.trails = make([][]TrailSegment, len(g.sim.Bodies))
-	for i := range g.sim.Bodies {
-		g.lastPos[i] = g.sim.Bodies[i].Pos
-		g.trails[i] = []TrailSegment{}
-		if g.sim.Bodies[i].ColorC == (color.RGBA{}) {
-			g.sim.Bodies[i].ColorC = color.RGBA{200, 200, 255, 255}
-		}
+	g.setSimulator(sim)
+	ebiten.SetWindowTitle("Gravity Simulation - " + g.snap.Name)
+	if g.watcher != nil {
+		// wczytany stan plików jest aktualny (lista dołączonych scen mogła się zmienić)
+		g.watcher.Reset()
 	}
-	// clear selections and histories
-	g.selA = -1
-	g.selB = -1
-	g.forceHistory = nil
-	g.fxHistory = nil
-	g.fyHistory = nil
 	// close modal and reset modes
 	g.addMode = false
 	g.resetModalOpen = false
+	g.reloadModalOpen = false
 	g.paused = false
 	return nil
 }
 
-func main() {
-	envName := flag.String("env", "solar", "Wybór środowiska (np. solar, binary, chaos)")
-	flag.Parse()
-	configPath := filepath.Join("pkg/assets", fmt.Sprintf("%s.json", *envName))
+// reloadScene wczytuje ponownie zmienioną scenę jak resetSimulation, ale zachowuje ustawienia
+// widoku: pauzę, tryb Add, dziennik zdarzeń i zaznaczenie z wykresem komponentów siły (o ile
+// ciała o tych ID nadal istnieją). Tempo symulacji i panel skrótów reset i tak pozostawia.
+func (g *Game) reloadScene() error {
+	paused, addMode := g.paused, g.addMode
+	selA, selB, showComponents := g.selA, g.selB, g.showComponents
+	eventLog := g.eventLog
+	if err := g.resetSimulation(); err != nil {
+		return err
+	}
+	g.paused, g.addMode = paused, addMode
+	g.eventLog = eventLog
+	g.selA, g.selB, g.showComponents = selA, selB, showComponents
+	// wykres siły zaczyna się od nowa, ale dla tej samej pary ciał
+	g.force.reset(g.selA, g.selB)
+	g.dropMissingSelection()
+	g.notify(fmt.Sprintf("wczytano ponownie scenę %s (%d ciał)", g.scene, len(g.snap.Bodies)))
+	return nil
+}
 
-	sim, err := simulation.LoadConfig(configPath)
-	if err != nil {
-		log.Fatalf("Błąd wczytywania środowiska: %v", err)
+// modalOpen - otwarty modal potwierdzenia (reset albo ponowne wczytanie sceny)
+func (g *Game) modalOpen() bool {
+	return g.resetModalOpen || g.reloadModalOpen
+}
+
+// confirmModal wykonuje akcję otwartego modalu
+func (g *Game) confirmModal() {
+	if g.resetModalOpen {
+		if err := g.resetSimulation(); err != nil {
+			log.Printf("Reset failed: %v", err)
+		}
+		return
+	}
+	if err := g.reloadScene(); err != nil {
+		// niepoprawny plik (np. zapisany w trakcie edycji) - zostajemy przy bieżącej symulacji,
+		// kolejny zapis pliku znów otworzy modal
+		log.Printf("Reload failed: %v", err)
+		g.reloadModalOpen = false
 	}
-	lastPos := make([]physics.Vec2, len(sim.Bodies))
-	trails := make([][]TrailSegment, len(sim.Bodies))
+}
+
+func (g *Game) closeModal() {
+	g.resetModalOpen = false
+	g.reloadModalOpen = false
+}
+
+// setSimulator zatrzymuje wątek poprzedniej symulacji, uruchamia nową i odbudowuje tablice
+// pomocnicze (ślady, ostatnie pozycje, zaznaczenie)
+func (g *Game) setSimulator(sim *simulation.Simulator) {
+	// nowa scena lub wczytany stan - trajektoria poprzedniej nie jest kontynuowana
+	g.stopRecording()
+	if g.engine != nil {
+		g.engine.Stop()
+	}
+	g.edits.Clear()
+	// symulator jest konfigurowany przed uruchomieniem wątku, potem tylko przez g.engine
+	if g.historyBudget > 0 {
+		sim.EnableHistory(g.historyBudget, g.keyframeEvery)
+	}
+	// ucieczki obsługujemy zawsze (usuwanie ciał), pozostałe zdarzenia tylko przy widocznym dzienniku,
+	// bo detektory bez subskrybentów nie są uruchamiane
+	sim.Events.Subscribe(g.deferred(g.onEscape), simulation.EventEscape)
+	sim.Attach(simulation.StepFunc(g.force.record))
 	for i := range sim.Bodies {
-		lastPos[i] = sim.Bodies[i].Pos
-		trails[i] = []TrailSegment{}
 		if sim.Bodies[i].ColorC == (color.RGBA{}) {
 			sim.Bodies[i].ColorC = color.RGBA{200, 200, 255, 255}
 		}
 	}
+	g.engine = engine.New(sim)
+	g.snap = g.engine.Snapshot()
+	// zdarzenia poprzedniej symulacji są już nieaktualne
+	g.inboxMu.Lock()
+	g.inbox = nil
+	g.inboxMu.Unlock()
+	g.eventLog = nil
+	g.eventLogUnsubscr = nil
+	g.setEventLogVisible(g.eventLogVisible)
+	// clear selections and histories
+	g.selA = ""
+	g.selB = ""
+	g.resyncBodies()
+}
+
+// resyncBodies odbudowuje ślady i ostatnie pozycje po skoku stanu (reset, przewinięcie historii);
+// zaznaczenie jest zachowane, o ile wskazuje na istniejące ciała
+func (g *Game) resyncBodies() {
+	g.lastPos = make(map[string]physics.Vec2, len(g.snap.Bodies))
+	g.trails = make(map[string][]TrailSegment, len(g.snap.Bodies))
+	for _, b := range g.snap.Bodies {
+		g.lastPos[b.ID] = b.Pos
+	}
+	g.dropMissingSelection()
+	g.force.reset(g.selA, g.selB)
+}
+
+// dropMissingSelection czyści zaznaczenie, jeśli któreś z zaznaczonych ciał już nie istnieje
+func (g *Game) dropMissingSelection() {
+	if (g.selA != "" && g.snap.Body(g.selA) == nil) || (g.selB != "" && g.snap.Body(g.selB) == nil) {
+		g.clearSelection()
+	}
+}
+
+// clearSelection odznacza oba ciała i czyści wykresy siły
+func (g *Game) clearSelection() {
+	g.selA, g.selB = "", ""
+	g.showComponents = false
+	g.force.reset("", "")
+}
+
+// do wykonuje edycję sceny przez historię edycji, dzięki czemu można ją cofnąć (Ctrl+Z)
+func (g *Game) do(cmd simulation.Command) {
+	if _, err := g.edit(func(sim *simulation.Simulator) (simulation.Command, error) {
+		return cmd, g.edits.Do(sim, cmd)
+	}); err != nil {
+		log.Printf("Edit failed: %v", err)
+	}
+}
+
+func (g *Game) undo() {
+	cmd, err := g.edit(g.edits.Undo)
+	if err != nil {
+		log.Printf("Undo failed: %v", err)
+		return
+	}
+	if cmd != nil {
+		g.notify("cofnięto: " + cmd.String())
+	}
+}
+
+func (g *Game) redo() {
+	cmd, err := g.edit(g.edits.Redo)
+	if err != nil {
+		log.Printf("Redo failed: %v", err)
+		return
+	}
+	if cmd != nil {
+		g.notify("ponowiono: " + cmd.String())
+	}
+}
+
+// edit wykonuje operację na historii edycji w wątku symulacji. Po wykonanej edycji zapisuje klatkę
+// historii, aby przewijanie nie gubiło zmian, i uzgadnia zaznaczenie z nową migawką
+// (ślady nowych ciał zaczynają się w następnym kroku).
+func (g *Game) edit(op func(*simulation.Simulator) (simulation.Command, error)) (simulation.Command, error) {
+	var cmd simulation.Command
+	err := g.engine.Call(func(sim *simulation.Simulator) error {
+		var err error
+		if cmd, err = op(sim); err != nil || cmd == nil {
+			return err
+		}
+		sim.RecordKeyframe()
+		return nil
+	})
+	if err != nil || cmd == nil {
+		return nil, err
+	}
+	g.refresh()
+	return cmd, nil
+}
+
+func (g *Game) scaleMass(f float64) {
+	g.do(&simulation.SetMassCmd{ID: g.selA, Mass: g.snap.Body(g.selA).Mass * f})
+}
+
+func (g *Game) scaleRadius(f float64) {
+	g.do(&simulation.SetRadiusCmd{ID: g.selA, Radius: g.snap.Body(g.selA).Radius * f})
+}
+
+func (g *Game) toggleLocked() {
+	g.do(&simulation.SetLockedCmd{ID: g.selA, Locked: !g.snap.Body(g.selA).Locked})
+}
+
+func (g *Game) toggleAnti() {
+	g.do(&simulation.SetAntiCmd{ID: g.selA, Anti: !g.snap.Body(g.selA).Anti})
+}
+
+// seek przewija symulację do kroku step (w granicach zapamiętanej historii)
+func (g *Game) seek(step int64) {
+	first, last := g.timelineRange()
+	step = max(first, min(step, last))
+	if err := g.engine.Call(func(sim *simulation.Simulator) error { return sim.SeekStep(step) }); err != nil {
+		log.Printf("Seek failed: %v", err)
+		return
+	}
+	g.refresh()
+	g.resyncBodies()
+}
+
+// timelineRange zwraca zakres kroków dostępnych na osi czasu
+func (g *Game) timelineRange() (int64, int64) {
+	h := g.snap.History
+	if !h.OK {
+		return g.snap.Step, g.snap.Step
+	}
+	return h.First, max(h.Last, g.snap.Step)
+}
+
+// deferred zwraca handler zdarzeń dla wątku symulacji: zdarzenie trafia do kolejki,
+// a fn jest wołane w następnym Update (w wątku interfejsu)
+func (g *Game) deferred(fn func(simulation.Event)) func(simulation.Event) {
+	return func(ev simulation.Event) {
+		g.post(func() { fn(ev) })
+	}
+}
+
+// post kolejkuje fn do wykonania w następnym Update; można wołać z dowolnego wątku
+func (g *Game) post(fn func()) {
+	g.inboxMu.Lock()
+	defer g.inboxMu.Unlock()
+	g.inbox = append(g.inbox, fn)
+}
+
+// apiControl - gra widziana przez API HTTP (api.Controller). Serwer działa w osobnych gorutynach,
+// więc silnik i pauza są udostępniane przez pola atomowe uzupełniane po każdym Update, a zmiana
+// pauzy jest wykonywana w Update (post).
+type apiControl struct {
+	g      *Game
+	engine atomic.Pointer[engine.Engine]
+	paused atomic.Bool
+}
+
+// share udostępnia serwerowi bieżący silnik i stan pauzy
+func (c *apiControl) share() {
+	if c == nil {
+		return
+	}
+	c.engine.Store(c.g.engine)
+	c.paused.Store(c.g.paused)
+}
+
+func (c *apiControl) Engine() *engine.Engine { return c.engine.Load() }
+
+func (c *apiControl) Paused() bool { return c.paused.Load() }
+
+// SetPaused czeka na najbliższy Update, który zmienia pauzę jak przycisk Pause. Gdy okno nie
+// odpowiada do końca ctx (np. jest wstrzymane), zwraca błąd, a zmiana wykona się w późniejszym Update.
+func (c *apiControl) SetPaused(ctx context.Context, paused bool) error {
+	done := make(chan struct{})
+	c.g.post(func() {
+		if c.g.paused != paused {
+			c.g.togglePause()
+		}
+		c.paused.Store(paused)
+		close(done)
+	})
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("okno symulacji nie odpowiada: %w", ctx.Err())
+	}
+}
+
+// Edit wykonuje edycję przez historię edycji gry (Ctrl+Z cofa także zmiany z API); historia edycji
+// jest używana tylko w wątku silnika, więc nie wymaga blokady
+func (c *apiControl) Edit(cmd simulation.Command) error {
+	err := c.Engine().Call(func(sim *simulation.Simulator) error {
+		if err := c.g.edits.Do(sim, cmd); err != nil {
+			return err
+		}
+		sim.RecordKeyframe()
+		return nil
+	})
+	if err == nil {
+		c.g.post(func() { c.g.notify("API: " + cmd.String()) })
+	}
+	return err
+}
+
+// onEscape obsługuje ucieczkę ciała z układu (przez kolejkę deferred, po usunięciu ciała z symulacji)
+func (g *Game) onEscape(ev simulation.Event) {
+	esc := ev.(simulation.EscapeEvent)
+	if !g.eventLogVisible {
+		g.logEvent(ev)
+	}
+	if esc.Removed && (esc.Body.ID == g.selA || esc.Body.ID == g.selB) {
+		// ciało zostało już usunięte; jego ślad wygaśnie sam
+		g.clearSelection()
+	}
+}
+
+// setEventLogVisible pokazuje lub ukrywa dziennik zdarzeń i (wy)rejestruje jego subskrypcję
+func (g *Game) setEventLogVisible(visible bool) {
+	g.eventLogVisible = visible
+	if visible && g.eventLogUnsubscr == nil {
+		g.eventLogUnsubscr = g.engine.Events().Subscribe(g.deferred(g.logEvent))
+	}
+	if !visible && g.eventLogUnsubscr != nil {
+		g.eventLogUnsubscr()
+		g.eventLogUnsubscr = nil
+	}
+}
+
+// logEvent dopisuje zdarzenie do dziennika
+func (g *Game) logEvent(ev simulation.Event) {
+	g.appendLog(fmt.Sprintf("t=%9.2f  %s", ev.When(), ev))
+}
+
+// notify dopisuje do dziennika komunikat interfejsu (np. o zapisie stanu)
+func (g *Game) notify(msg string) {
+	log.Print(msg)
+	g.appendLog(fmt.Sprintf("t=%9.2f  %s", g.snap.Time, msg))
+}
+
+func (g *Game) appendLog(line string) {
+	g.eventLog = append(g.eventLog, line)
+	if len(g.eventLog) > eventLogMax {
+		g.eventLog = g.eventLog[len(g.eventLog)-eventLogMax:]
+	}
+}
+
+func main() {
+	envName := flag.String("env", "solar", "Nazwa sceny z GRAVITY_SIM_PATH lub wbudowanej (np. solar, 3body, flyby)")
+	configPath := flag.String("config", "", "Ścieżka do dowolnego pliku sceny JSON (zamiast -env)")
+	checkpointPath := flag.String("checkpoint", "gravity-sim.ckpt.json", "Plik stanu symulacji (Ctrl+S zapis, Ctrl+O odczyt)")
+	historyMB := flag.Int("history-mb", 64, "Limit pamięci historii do przewijania w MB (0 = wyłączona)")
+	keyframeEvery := flag.Int("keyframe", 10, "Odstęp klatek historii w krokach")
+	speed := flag.Int("speed", 1, "Liczba kroków symulacji na klatkę")
+	realtime := flag.Float64("realtime", 0, "Tempo w jednostkach czasu symulacji na sekundę (0 = tryb kroków na klatkę)")
+	recordFormat := flag.String("record-format", "csv", "Format zapisu trajektorii przyciskiem Rec (csv, ndjson)")
+	recordEvery := flag.Int("record-every", 1, "Zapis trajektorii co K kroków")
+	exportFormat := flag.String("export-format", "vtk", "Format migawki zapisywanej Ctrl+E (vtk, xyz)")
+	watch := flag.Duration("watch", assets.DefaultWatchInterval, "Odstęp sprawdzania zmian pliku sceny (0 = bez obserwowania)")
+	apiAddr := flag.String("api", "", "Adres lokalnego API HTTP do sterowania symulacją, np. 127.0.0.1:8080 (tylko pętla zwrotna, puste = wyłączone)")
+	flag.Parse()
+	recFormat, err := trajectory.ParseFormat(*recordFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	expFormat, err := export.ParseFormat(*exportFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	scene := assets.FromFile(*configPath)
+	if *configPath == "" {
+		if scene, err = assets.Find(*envName); err != nil {
+			log.Fatalf("Błąd wczytywania środowiska: %v", err)
+		}
+	}
+
+	sim, err := scene.Load()
+	if err != nil {
+		log.Fatalf("Błąd wczytywania środowiska: %v", err)
+	}
 	game := &Game{
-		sim:               sim,
-		trails:            trails,
-		lastPos:           lastPos,
-		selA:              -1,
-		selB:              -1,
-		forceHistoryMax:   600,
-		shortcutsVisible:  true,
-		initialConfigPath: configPath,
+		force:            &forceGraph{max: 600},
+		edits:            simulation.EditHistory{Limit: 500},
+		shortcutsVisible: true,
+		eventLogVisible:  true,
+		scene:            scene,
+		checkpointPath:   *checkpointPath,
+		historyBudget:    *historyMB << 20,
+		keyframeEvery:    *keyframeEvery,
+		substeps:         max(1, min(maxSubsteps, *speed)),
+		realtime:         *realtime > 0,
+		simRate:          *realtime,
+		recordFormat:     recFormat,
+		recordEvery:      max(1, *recordEvery),
+		exportFormat:     expFormat,
+	}
+	if *watch > 0 {
+		game.watcher = assets.NewWatcher(scene)
+		game.watcher.Interval = *watch
+	}
+	if game.simRate <= 0 {
+		// domyślnie tempo odpowiadające jednemu krokowi na klatkę
+		game.simRate = sim.Dt * float64(ebiten.TPS())
+	}
+	game.setSimulator(sim)
+	if *apiAddr != "" {
+		game.api = &apiControl{g: game}
+		game.api.share()
+		srv := api.NewServer(game.api)
+		srv.CheckpointPath = *checkpointPath
+		ln, err := api.Listen(*apiAddr)
+		if err != nil {
+			log.Fatalf("Błąd uruchamiania API: %v", err)
+		}
+		log.Printf("API symulacji: http://%s", ln.Addr())
+		go func() { log.Print(http.Serve(ln, srv)) }()
 	}
 	ebiten.SetWindowSize(screenWidth, screenHeight)
-	ebiten.SetWindowTitle("Gravity Simulation - " + sim.Name)
-	if err := ebiten.RunGame(game); err != nil {
+	ebiten.SetWindowTitle("Gravity Simulation - " + game.snap.Name)
+	err = ebiten.RunGame(game)
+	// przycisk Quit albo zamknięcie okna: dokończ zapis trajektorii (bufor, indeks .gtraj)
+	game.stopRecording()
+	game.engine.Stop()
+	if err != nil {
 		log.Fatal(err)
 	}
 }
 
-// drawResetModal rysuje modal potwierdzenia resetu (nowa, czysta wersja)
-func drawResetModal(screen *ebiten.Image) {
+// drawConfirmModal rysuje modal potwierdzenia z przyciskami Yes / No
+func drawConfirmModal(screen *ebiten.Image, title, detail string) {
 	w := 360
 	h := 120
 	x := (screenWidth - w) / 2
@@ -1100,8 +1861,8 @@ func drawResetModal(screen *ebiten.Image) {
 	op.GeoM.Translate(float64(x+2), float64(y+2))
 	panel.DrawImage(inner, op)
 
-	text.Draw(panel, "Reset simulation?", basicfont.Face7x13, 16, 28, color.RGBA{230, 230, 230, 255})
-	text.Draw(panel, "Reload initial config and remove added bodies.", basicfont.Face7x13, 16, 48, color.RGBA{190, 190, 190, 200})
+	text.Draw(panel, title, basicfont.Face7x13, 16, 28, color.RGBA{230, 230, 230, 255})
+	text.Draw(panel, detail, basicfont.Face7x13, 16, 48, color.RGBA{190, 190, 190, 200})
 
 	yesX := 40
 	noX := w - 40 - uiBtnW
@@ -1127,6 +1888,17 @@ func maxf(a, b float64) float64 {
 	return b
 }
 
+// keyRepeat - klawisz właśnie wciśnięty albo przytrzymany (powtarzanie co 3 klatki po krótkiej zwłoce)
+func keyRepeat(k ebiten.Key) bool {
+	d := inpututil.KeyPressDuration(k)
+	return d == 1 || (d > 20 && d%3 == 0)
+}
+
+// ctrlPressed - wciśnięty Ctrl (lub Cmd na macOS)
+func ctrlPressed() bool {
+	return ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta)
+}
+
 func pointInRect(px, py, rx, ry, rw, rh int) bool {
 	return px >= rx && px <= rx+rw && py >= ry && py <= ry+rh
 }
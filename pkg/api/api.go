@@ -0,0 +1,482 @@
+// Package api - lokalne API HTTP do sterowania działającą symulacją ze skryptów i notebooków.
+// Serwer działa z oknem GUI i bez niego (gsim serve, patrz Runner); stan czyta z migawek
+// silnika, a zmiany wykonuje w wątku silnika (engine.Engine.Call). Ciała w zapytaniach
+// i odpowiedziach mają pola jak w pliku sceny (simulation.BodyConfig).
+//
+//	GET    /state              stan symulacji z listą ciał
+//	GET    /bodies             lista ciał
+//	GET    /bodies/{id}        jedno ciało
+//	POST   /bodies             dodanie ciała (BodyConfig, opcjonalnie "id")
+//	PATCH  /bodies/{id}        zmiana podanych pól ciała
+//	DELETE /bodies/{id}        usunięcie ciała
+//	POST   /pause, /resume     wstrzymanie i wznowienie
+//	POST   /step               {"steps": n} - n kroków (domyślnie 1), także w pauzie
+//	PUT    /integrator         {"integrator": "leapfrog"} - zmiana metody całkowania
+//	POST   /checkpoint         zapis stanu do Server.CheckpointPath
+//
+// Błędy są zwracane jako {"error": "..."} z kodem 400, 403, 404, 415 lub 503 (symulacja została
+// podmieniona albo zatrzymana, albo okno nie odpowiada - zapytanie można powtórzyć).
+//
+// API nie ma uwierzytelniania, dlatego serwer przyjmuje połączenia tylko na adresach pętli
+// zwrotnej (Listen), zapytania zmieniające stan muszą mieć Content-Type: application/json,
+// a zapytania przeglądarki z innych stron są odrzucane (http.CrossOriginProtection).
+// Ścieżki plików nie pochodzą od klienta.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gravity-sim/pkg/engine"
+	"gravity-sim/pkg/physics"
+	"gravity-sim/pkg/simulation"
+)
+
+// maxStepsPerRequest - limit kroków jednego POST /step (silnik nie obsługuje w tym czasie innych poleceń)
+const maxStepsPerRequest = 1000000
+
+// maxBodySize - limit rozmiaru treści zapytania
+const maxBodySize = 1 << 20
+
+// pauseTimeout - czas oczekiwania na zmianę pauzy (w GUI wykonuje ją dopiero najbliższa klatka)
+const pauseTimeout = 2 * time.Second
+
+// Controller - symulacja sterowana przez API: okno GUI albo Runner w trybie bez okna.
+// Metody są wołane z gorutyn serwera HTTP.
+type Controller interface {
+	// Engine zwraca silnik bieżącej symulacji (GUI podmienia go przy resecie i wczytaniu stanu)
+	Engine() *engine.Engine
+	// Edit wykonuje edycję sceny w wątku silnika (GUI zapisuje ją w historii, więc Ctrl+Z ją cofa)
+	Edit(cmd simulation.Command) error
+	Paused() bool
+	// SetPaused wstrzymuje lub wznawia symulację; błąd, gdy nie udało się tego zrobić przed końcem ctx
+	SetPaused(ctx context.Context, paused bool) error
+}
+
+// Body - ciało w zapytaniach i odpowiedziach: identyfikator i pola jak w pliku sceny
+type Body struct {
+	ID string `json:"id"`
+	simulation.BodyConfig
+}
+
+// State - odpowiedź GET /state i POST /step
+type State struct {
+	Name       string  `json:"name"`
+	Step       int64   `json:"step"`
+	Time       float64 `json:"time"`
+	Dt         float64 `json:"dt"`
+	Integrator string  `json:"integrator"`
+	Paused     bool    `json:"paused"`
+	Bodies     []Body  `json:"bodies"`
+}
+
+// Server - handler HTTP API; NewServer rejestruje ścieżki
+type Server struct {
+	// CheckpointPath - plik stanu zapisywany przez POST /checkpoint ("" = zapis wyłączony)
+	CheckpointPath string
+
+	ctl     Controller
+	mux     *http.ServeMux
+	handler http.Handler
+}
+
+func NewServer(ctl Controller) *Server {
+	s := &Server{ctl: ctl, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /state", s.getState)
+	s.mux.HandleFunc("GET /bodies", s.getBodies)
+	s.mux.HandleFunc("GET /bodies/{id}", s.getBody)
+	s.mux.HandleFunc("POST /bodies", s.addBody)
+	s.mux.HandleFunc("PATCH /bodies/{id}", s.editBody)
+	s.mux.HandleFunc("DELETE /bodies/{id}", s.removeBody)
+	s.mux.HandleFunc("POST /pause", s.pause(true))
+	s.mux.HandleFunc("POST /resume", s.pause(false))
+	s.mux.HandleFunc("POST /step", s.step)
+	s.mux.HandleFunc("PUT /integrator", s.setIntegrator)
+	s.mux.HandleFunc("POST /checkpoint", s.checkpoint)
+	cop := http.NewCrossOriginProtection()
+	cop.SetDenyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &statusError{http.StatusForbidden, errors.New("zapytania z innych stron są odrzucane")})
+	}))
+	s.handler = cop.Handler(requireJSON(s.mux))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// requireJSON odrzuca zapytania POST, PUT i PATCH bez Content-Type: application/json. Formularz
+// HTML nie może wysłać takiego zapytania, a fetch z innej strony wymaga zgody CORS, której serwer
+// nie daje - dzięki temu obca strona nie zmieni stanu symulacji nawet bez nagłówka Origin.
+func requireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+				writeError(w, &statusError{http.StatusUnsupportedMediaType, errors.New("wymagany Content-Type: application/json")})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Listen otwiera gniazdo serwera API; adresy inne niż pętla zwrotna (localhost, 127.0.0.1, ::1)
+// są odrzucane, bo API nie ma uwierzytelniania
+func Listen(addr string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !loopback(host) {
+		return nil, fmt.Errorf("API może nasłuchiwać tylko na adresie pętli zwrotnej (np. 127.0.0.1:8080), a nie %q", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+func loopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// --- Odpowiedzi i błędy ---
+
+// statusError - błąd z kodem odpowiedzi HTTP
+type statusError struct {
+	code int
+	err  error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+
+func (e *statusError) Unwrap() error { return e.err }
+
+func badRequest(format string, a ...any) error {
+	return &statusError{http.StatusBadRequest, fmt.Errorf(format, a...)}
+}
+
+func notFound(id string) error {
+	return &statusError{http.StatusNotFound, fmt.Errorf("brak ciała %q", id)}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusBadRequest
+	var se *statusError
+	switch {
+	case errors.As(err, &se):
+		code = se.code
+	case errors.Is(err, engine.ErrStopped):
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// decode odczytuje treść zapytania JSON do v i zwraca ją; nieznane pola są błędem (literówki
+// w nazwach pól), a pusta treść zostawia v bez zmian
+func decode(r *http.Request, v any) ([]byte, error) {
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBodySize))
+	if err != nil {
+		return nil, badRequest("błąd odczytu zapytania: %v", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return data, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return nil, badRequest("niepoprawny JSON: %v", err)
+	}
+	return data, nil
+}
+
+// --- Stan ---
+
+func bodyOf(b physics.Body) Body {
+	return Body{ID: b.ID, BodyConfig: simulation.BodyConfigOf(b)}
+}
+
+func (s *Server) state(snap *engine.Snapshot) State {
+	st := State{
+		Name:       snap.Name,
+		Step:       snap.Step,
+		Time:       snap.Time,
+		Dt:         snap.Dt,
+		Integrator: snap.Integrator,
+		Paused:     s.ctl.Paused(),
+		Bodies:     make([]Body, len(snap.Bodies)),
+	}
+	for i, b := range snap.Bodies {
+		st.Bodies[i] = bodyOf(b)
+	}
+	return st
+}
+
+func (s *Server) getState(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.state(s.ctl.Engine().Snapshot()))
+}
+
+func (s *Server) getBodies(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.state(s.ctl.Engine().Snapshot()).Bodies)
+}
+
+func (s *Server) getBody(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	b := s.ctl.Engine().Snapshot().Body(id)
+	if b == nil {
+		writeError(w, notFound(id))
+		return
+	}
+	writeJSON(w, http.StatusOK, bodyOf(*b))
+}
+
+// --- Edycja ciał ---
+
+// checkBody sprawdza wartości pól nowego ciała
+func checkBody(cfg simulation.BodyConfig) error {
+	for _, v := range []float64{cfg.Mass, cfg.Radius, cfg.Pos[0], cfg.Pos[1], cfg.Vel[0], cfg.Vel[1]} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return badRequest("wartości ciała muszą być skończone")
+		}
+	}
+	if cfg.Mass < 0 {
+		return badRequest("masa nie może być ujemna (%g)", cfg.Mass)
+	}
+	if cfg.Radius <= 0 {
+		return badRequest("promień musi być dodatni (%g)", cfg.Radius)
+	}
+	return nil
+}
+
+func (s *Server) addBody(w http.ResponseWriter, r *http.Request) {
+	var req Body
+	if _, err := decode(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := checkBody(req.BodyConfig); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Color != "" {
+		if _, err := simulation.ParseColor(req.Color); err != nil {
+			writeError(w, badRequest("%v", err))
+			return
+		}
+	}
+	b := req.Body()
+	b.ID = req.ID
+	cmd := &simulation.AddBodyCmd{Body: b}
+	if err := s.ctl.Edit(cmd); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, s.bodyAfterEdit(cmd.Body.ID, cmd.Body))
+}
+
+// bodyAfterEdit zwraca ciało z migawki po edycji albo fallback, gdy ciała już w niej nie ma
+func (s *Server) bodyAfterEdit(id string, fallback physics.Body) Body {
+	if b := s.ctl.Engine().Snapshot().Body(id); b != nil {
+		return bodyOf(*b)
+	}
+	return bodyOf(fallback)
+}
+
+func (s *Server) editBody(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	cur := s.ctl.Engine().Snapshot().Body(id)
+	if cur == nil {
+		writeError(w, notFound(id))
+		return
+	}
+	// treść jest czytana jako BodyConfig (wartości) i jako mapa (które pola podano)
+	var req simulation.BodyConfig
+	data, err := decode(r, &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var fields map[string]json.RawMessage
+	if len(bytes.TrimSpace(data)) > 0 {
+		json.Unmarshal(data, &fields)
+	}
+	cmd, err := editCmd(id, req, fields)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.ctl.Edit(cmd); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.bodyAfterEdit(id, *cur))
+}
+
+// editCmd tworzy edycję pól ciała podanych w zapytaniu
+func editCmd(id string, req simulation.BodyConfig, fields map[string]json.RawMessage) (*simulation.EditBodyCmd, error) {
+	if len(fields) == 0 {
+		return nil, badRequest("brak pól do zmiany")
+	}
+	cmd := &simulation.EditBodyCmd{ID: id}
+	for name := range fields {
+		switch name {
+		case "name":
+			cmd.Name = &req.Name
+		case "mass":
+			if req.Mass < 0 || math.IsNaN(req.Mass) || math.IsInf(req.Mass, 0) {
+				return nil, badRequest("masa musi być skończona i nieujemna (%g)", req.Mass)
+			}
+			cmd.Mass = &req.Mass
+		case "pos":
+			cmd.Pos = &physics.Vec2{X: req.Pos[0], Y: req.Pos[1]}
+		case "vel":
+			cmd.Vel = &physics.Vec2{X: req.Vel[0], Y: req.Vel[1]}
+		case "radius":
+			if req.Radius <= 0 || math.IsInf(req.Radius, 0) {
+				return nil, badRequest("promień musi być dodatni (%g)", req.Radius)
+			}
+			cmd.Radius = &req.Radius
+		case "color":
+			c, err := simulation.ParseColor(req.Color)
+			if err != nil {
+				return nil, badRequest("%v", err)
+			}
+			cmd.Color = &c
+		case "locked":
+			cmd.Locked = &req.Locked
+		case "anti":
+			cmd.Anti = &req.Anti
+		case "tags":
+			cmd.Tags = append([]string{}, req.Tags...)
+		}
+	}
+	for _, v := range []*physics.Vec2{cmd.Pos, cmd.Vel} {
+		if v != nil && (math.IsNaN(v.X+v.Y) || math.IsInf(v.X+v.Y, 0)) {
+			return nil, badRequest("wartości ciała muszą być skończone")
+		}
+	}
+	return cmd, nil
+}
+
+func (s *Server) removeBody(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if s.ctl.Engine().Snapshot().Body(id) == nil {
+		writeError(w, notFound(id))
+		return
+	}
+	if err := s.ctl.Edit(&simulation.RemoveBodyCmd{ID: id}); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Sterowanie ---
+
+func (s *Server) pause(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), pauseTimeout)
+		defer cancel()
+		if err := s.ctl.SetPaused(ctx, paused); err != nil {
+			writeError(w, &statusError{http.StatusServiceUnavailable, err})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]bool{"paused": s.ctl.Paused()})
+	}
+}
+
+func (s *Server) step(w http.ResponseWriter, r *http.Request) {
+	req := struct {
+		Steps int `json:"steps"`
+	}{Steps: 1}
+	if _, err := decode(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Steps <= 0 || req.Steps > maxStepsPerRequest {
+		writeError(w, badRequest("liczba kroków musi być w zakresie 1..%d (jest %d)", maxStepsPerRequest, req.Steps))
+		return
+	}
+	e := s.ctl.Engine()
+	if err := e.Call(func(sim *simulation.Simulator) error {
+		sim.Advance(req.Steps)
+		return nil
+	}); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.state(e.Snapshot()))
+}
+
+func (s *Server) setIntegrator(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Integrator string `json:"integrator"`
+	}
+	if _, err := decode(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if _, ok := physics.Integrators[req.Integrator]; !ok {
+		names := make([]string, 0, len(physics.Integrators))
+		for name := range physics.Integrators {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		writeError(w, badRequest("nieznana metoda całkowania %q (%s)", req.Integrator, strings.Join(names, ", ")))
+		return
+	}
+	if err := s.ctl.Engine().Call(func(sim *simulation.Simulator) error {
+		sim.Integrator = req.Integrator
+		return nil
+	}); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, req)
+}
+
+// checkpoint zapisuje stan do pliku ustawionego przy uruchomieniu serwera; klient nie wybiera
+// ścieżki, więc API nie pozwala zapisać pliku w dowolnym miejscu
+func (s *Server) checkpoint(w http.ResponseWriter, r *http.Request) {
+	if _, err := decode(r, &struct{}{}); err != nil {
+		writeError(w, err)
+		return
+	}
+	path := s.CheckpointPath
+	if path == "" {
+		writeError(w, badRequest("zapis stanu przez API jest wyłączony (brak pliku stanu)"))
+		return
+	}
+	var step int64
+	if err := s.ctl.Engine().Call(func(sim *simulation.Simulator) error {
+		step = sim.Step
+		if err := sim.Save(path); err != nil {
+			// błąd zapisu na dysku serwera, nie błąd zapytania
+			return &statusError{http.StatusInternalServerError, err}
+		}
+		return nil
+	}); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"path": path, "step": step})
+}
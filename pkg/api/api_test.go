@@ -0,0 +1,373 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gravity-sim/pkg/engine"
+	"gravity-sim/pkg/simulation"
+)
+
+// newTestServer tworzy serwer dla sceny z dwoma ciałami ("sun" i "planet"); kroki wykonuje
+// tylko POST /step, bo Runner nie jest uruchomiony
+func newTestServer(t *testing.T) (*Server, *Runner) {
+	t.Helper()
+	sim := simulation.NewSimulator(simulation.EnvironmentConfig{
+		Name: "test",
+		Dt:   0.01,
+		Bodies: []simulation.BodyConfig{
+			{Name: "sun", Mass: 1000, Radius: 10, Color: "#ffff00", Locked: true},
+			{Name: "planet", Mass: 1, Pos: [2]float64{100, 0}, Vel: [2]float64{0, 3}, Radius: 2, Color: "#0000ff"},
+		},
+	})
+	e := engine.New(sim)
+	t.Cleanup(func() { e.Stop() })
+	runner := NewRunner(e)
+	return NewServer(runner), runner
+}
+
+// do wysyła zapytanie do serwera; zapytania z treścią dostają Content-Type: application/json
+func do(t *testing.T, h http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if method != http.MethodGet && method != http.MethodDelete {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// expect sprawdza kod odpowiedzi i odczytuje jej treść JSON do v (v == nil - bez odczytu)
+func expect(t *testing.T, w *httptest.ResponseRecorder, code int, v any) {
+	t.Helper()
+	if w.Code != code {
+		t.Fatalf("kod %d, oczekiwano %d: %s", w.Code, code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); code != http.StatusNoContent && ct != "application/json" {
+		t.Fatalf("Content-Type %q, oczekiwano application/json", ct)
+	}
+	if v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("niepoprawna odpowiedź JSON: %v: %s", err, w.Body.String())
+		}
+	}
+}
+
+// expectError sprawdza kod błędu i to, że odpowiedź ma postać {"error": "..."}
+func expectError(t *testing.T, w *httptest.ResponseRecorder, code int) {
+	t.Helper()
+	var resp struct {
+		Error string `json:"error"`
+	}
+	expect(t, w, code, &resp)
+	if resp.Error == "" {
+		t.Fatalf("brak komunikatu błędu: %s", w.Body.String())
+	}
+}
+
+func TestGetState(t *testing.T) {
+	srv, _ := newTestServer(t)
+	var st State
+	expect(t, do(t, srv, "GET", "/state", ""), http.StatusOK, &st)
+	if st.Name != "test" || st.Step != 0 || st.Dt != 0.01 || len(st.Bodies) != 2 {
+		t.Fatalf("nieoczekiwany stan: %+v", st)
+	}
+	if st.Bodies[0].ID != "sun" || st.Bodies[1].ID != "planet" {
+		t.Fatalf("identyfikatory %q, %q", st.Bodies[0].ID, st.Bodies[1].ID)
+	}
+
+	var bodies []Body
+	expect(t, do(t, srv, "GET", "/bodies", ""), http.StatusOK, &bodies)
+	if len(bodies) != 2 {
+		t.Fatalf("GET /bodies: %d ciał", len(bodies))
+	}
+}
+
+func TestGetBody(t *testing.T) {
+	srv, _ := newTestServer(t)
+	var b Body
+	expect(t, do(t, srv, "GET", "/bodies/planet", ""), http.StatusOK, &b)
+	if b.ID != "planet" || b.Mass != 1 || b.Pos != [2]float64{100, 0} || b.Color != "#0000ff" {
+		t.Fatalf("nieoczekiwane ciało: %+v", b)
+	}
+	expectError(t, do(t, srv, "GET", "/bodies/moon", ""), http.StatusNotFound)
+}
+
+func TestAddBody(t *testing.T) {
+	srv, _ := newTestServer(t)
+	var b Body
+	w := do(t, srv, "POST", "/bodies", `{"id": "moon", "mass": 0.1, "pos": [110, 0], "vel": [0, 4], "radius": 1, "color": "#cccccc", "tags": ["moon"]}`)
+	expect(t, w, http.StatusCreated, &b)
+	if b.ID != "moon" || b.Mass != 0.1 || len(b.Tags) != 1 {
+		t.Fatalf("nieoczekiwane ciało: %+v", b)
+	}
+	expect(t, do(t, srv, "GET", "/bodies/moon", ""), http.StatusOK, nil)
+
+	// bez identyfikatora ciało dostaje nazwę albo identyfikator automatyczny
+	expect(t, do(t, srv, "POST", "/bodies", `{"name": "comet", "mass": 0, "radius": 1}`), http.StatusCreated, &b)
+	if b.ID != "comet" {
+		t.Fatalf("identyfikator %q, oczekiwano comet", b.ID)
+	}
+
+	for _, tc := range []struct{ name, body string }{
+		{"ujemna masa", `{"mass": -1, "radius": 1}`},
+		{"zerowy promień", `{"mass": 1, "radius": 0}`},
+		{"zły kolor", `{"mass": 1, "radius": 1, "color": "red"}`},
+		{"nieznane pole", `{"mass": 1, "radius": 1, "masss": 2}`},
+		{"zły JSON", `{"mass": 1,`},
+		{"zajęty identyfikator", `{"id": "sun", "mass": 1, "radius": 1}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expectError(t, do(t, srv, "POST", "/bodies", tc.body), http.StatusBadRequest)
+		})
+	}
+	var bodies []Body
+	expect(t, do(t, srv, "GET", "/bodies", ""), http.StatusOK, &bodies)
+	if len(bodies) != 4 {
+		t.Fatalf("po błędnych zapytaniach jest %d ciał, oczekiwano 4", len(bodies))
+	}
+}
+
+func TestEditBody(t *testing.T) {
+	srv, _ := newTestServer(t)
+	var b Body
+	expect(t, do(t, srv, "PATCH", "/bodies/planet", `{"mass": 5, "color": "#ff0000"}`), http.StatusOK, &b)
+	if b.Mass != 5 || b.Color != "#ff0000" {
+		t.Fatalf("pola nie zostały zmienione: %+v", b)
+	}
+	// pola, których nie podano, zostają bez zmian
+	if b.Pos != [2]float64{100, 0} || b.Vel != [2]float64{0, 3} || b.Radius != 2 {
+		t.Fatalf("zmienione pola, których nie podano: %+v", b)
+	}
+
+	expectError(t, do(t, srv, "PATCH", "/bodies/moon", `{"mass": 1}`), http.StatusNotFound)
+	for _, tc := range []struct{ name, body string }{
+		{"pusta treść", ``},
+		{"puste pola", `{}`},
+		{"ujemna masa", `{"mass": -1}`},
+		{"zerowy promień", `{"radius": 0}`},
+		{"zły kolor", `{"color": "#12"}`},
+		{"nieznane pole", `{"speed": 1}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expectError(t, do(t, srv, "PATCH", "/bodies/planet", tc.body), http.StatusBadRequest)
+		})
+	}
+}
+
+func TestRemoveBody(t *testing.T) {
+	srv, _ := newTestServer(t)
+	expect(t, do(t, srv, "DELETE", "/bodies/planet", ""), http.StatusNoContent, nil)
+	expectError(t, do(t, srv, "GET", "/bodies/planet", ""), http.StatusNotFound)
+	expectError(t, do(t, srv, "DELETE", "/bodies/planet", ""), http.StatusNotFound)
+}
+
+func TestPauseResume(t *testing.T) {
+	srv, runner := newTestServer(t)
+	var resp map[string]bool
+	expect(t, do(t, srv, "POST", "/pause", ""), http.StatusOK, &resp)
+	if !resp["paused"] || !runner.Paused() {
+		t.Fatalf("symulacja nie jest wstrzymana: %v", resp)
+	}
+	var st State
+	expect(t, do(t, srv, "GET", "/state", ""), http.StatusOK, &st)
+	if !st.Paused {
+		t.Fatal("GET /state: paused = false")
+	}
+	expect(t, do(t, srv, "POST", "/resume", ""), http.StatusOK, &resp)
+	if resp["paused"] || runner.Paused() {
+		t.Fatalf("symulacja nie została wznowiona: %v", resp)
+	}
+}
+
+// stalledControl - Controller, który nie zmienia pauzy (jak okno GUI, które nie wykonuje Update)
+type stalledControl struct {
+	*Runner
+}
+
+func (c stalledControl) SetPaused(ctx context.Context, paused bool) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestPauseStalled(t *testing.T) {
+	_, runner := newTestServer(t)
+	srv := NewServer(stalledControl{runner})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest("POST", "/pause", nil).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, r)
+	expectError(t, w, http.StatusServiceUnavailable)
+}
+
+func TestStep(t *testing.T) {
+	srv, _ := newTestServer(t)
+	var st State
+	expect(t, do(t, srv, "POST", "/step", ""), http.StatusOK, &st)
+	if st.Step != 1 {
+		t.Fatalf("krok %d po POST /step bez treści, oczekiwano 1", st.Step)
+	}
+	expect(t, do(t, srv, "POST", "/step", `{"steps": 10}`), http.StatusOK, &st)
+	if st.Step != 11 {
+		t.Fatalf("krok %d, oczekiwano 11", st.Step)
+	}
+	if st.Bodies[1].Pos == [2]float64{100, 0} {
+		t.Fatal("ciało nie poruszyło się")
+	}
+
+	// kroki działają także w pauzie
+	expect(t, do(t, srv, "POST", "/pause", ""), http.StatusOK, nil)
+	expect(t, do(t, srv, "POST", "/step", `{"steps": 2}`), http.StatusOK, &st)
+	if st.Step != 13 || !st.Paused {
+		t.Fatalf("krok %d (pauza %v), oczekiwano 13 w pauzie", st.Step, st.Paused)
+	}
+
+	for _, body := range []string{`{"steps": 0}`, `{"steps": -5}`, `{"steps": 1000001}`, `{"steps": "1"}`} {
+		expectError(t, do(t, srv, "POST", "/step", body), http.StatusBadRequest)
+	}
+}
+
+func TestIntegrator(t *testing.T) {
+	srv, _ := newTestServer(t)
+	expect(t, do(t, srv, "PUT", "/integrator", `{"integrator": "leapfrog"}`), http.StatusOK, nil)
+	var st State
+	expect(t, do(t, srv, "GET", "/state", ""), http.StatusOK, &st)
+	if st.Integrator != "leapfrog" {
+		t.Fatalf("metoda całkowania %q, oczekiwano leapfrog", st.Integrator)
+	}
+	expectError(t, do(t, srv, "PUT", "/integrator", `{"integrator": "rk99"}`), http.StatusBadRequest)
+	expectError(t, do(t, srv, "PUT", "/integrator", `{}`), http.StatusBadRequest)
+}
+
+func TestCheckpoint(t *testing.T) {
+	srv, _ := newTestServer(t)
+	expectError(t, do(t, srv, "POST", "/checkpoint", ""), http.StatusBadRequest)
+
+	srv.CheckpointPath = filepath.Join(t.TempDir(), "state.ckpt.json")
+	expect(t, do(t, srv, "POST", "/step", `{"steps": 3}`), http.StatusOK, nil)
+	var resp struct {
+		Path string `json:"path"`
+		Step int64  `json:"step"`
+	}
+	expect(t, do(t, srv, "POST", "/checkpoint", ""), http.StatusOK, &resp)
+	if resp.Path != srv.CheckpointPath || resp.Step != 3 {
+		t.Fatalf("nieoczekiwana odpowiedź: %+v", resp)
+	}
+	sim, err := simulation.Restore(srv.CheckpointPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim.Step != 3 || len(sim.Bodies) != 2 {
+		t.Fatalf("odczytany stan: krok %d, %d ciał", sim.Step, len(sim.Bodies))
+	}
+
+	// klient nie może wybrać ścieżki pliku
+	other := filepath.Join(t.TempDir(), "other.json")
+	expectError(t, do(t, srv, "POST", "/checkpoint", `{"path": "`+other+`"}`), http.StatusBadRequest)
+	if _, err := os.Stat(other); !os.IsNotExist(err) {
+		t.Fatalf("zapisano plik wskazany przez klienta (%v)", err)
+	}
+
+	// błąd zapisu po stronie serwera (brak katalogu) to 500, a nie błąd zapytania
+	srv.CheckpointPath = filepath.Join(t.TempDir(), "missing", "state.ckpt.json")
+	expectError(t, do(t, srv, "POST", "/checkpoint", ""), http.StatusInternalServerError)
+}
+
+func TestRequireJSON(t *testing.T) {
+	srv, runner := newTestServer(t)
+	for _, ct := range []string{"", "text/plain", "application/x-www-form-urlencoded", "multipart/form-data; boundary=x"} {
+		r := httptest.NewRequest("POST", "/pause", strings.NewReader(""))
+		if ct != "" {
+			r.Header.Set("Content-Type", ct)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, r)
+		expectError(t, w, http.StatusUnsupportedMediaType)
+	}
+	if runner.Paused() {
+		t.Fatal("zapytanie bez JSON wstrzymało symulację")
+	}
+	expect(t, do(t, srv, "POST", "/pause", "", "Content-Type", "application/json; charset=utf-8"), http.StatusOK, nil)
+	// GET nie wymaga nagłówka
+	expect(t, do(t, srv, "GET", "/state", ""), http.StatusOK, nil)
+}
+
+func TestCrossOrigin(t *testing.T) {
+	srv, runner := newTestServer(t)
+	expectError(t, do(t, srv, "POST", "/pause", "", "Sec-Fetch-Site", "cross-site"), http.StatusForbidden)
+	expectError(t, do(t, srv, "POST", "/pause", "", "Origin", "https://evil.example"), http.StatusForbidden)
+	if runner.Paused() {
+		t.Fatal("zapytanie z innej strony wstrzymało symulację")
+	}
+	// zapytania z tej samej strony i spoza przeglądarki (bez nagłówków) są przyjmowane
+	expect(t, do(t, srv, "POST", "/pause", "", "Sec-Fetch-Site", "same-origin"), http.StatusOK, nil)
+	expect(t, do(t, srv, "POST", "/resume", ""), http.StatusOK, nil)
+	// odczyt stanu jest bezpieczny także z innych stron
+	expect(t, do(t, srv, "GET", "/state", "", "Sec-Fetch-Site", "cross-site"), http.StatusOK, nil)
+}
+
+func TestStoppedEngine(t *testing.T) {
+	srv, runner := newTestServer(t)
+	runner.Engine().Stop()
+	expectError(t, do(t, srv, "POST", "/step", ""), http.StatusServiceUnavailable)
+	expectError(t, do(t, srv, "POST", "/bodies", `{"mass": 1, "radius": 1}`), http.StatusServiceUnavailable)
+	expectError(t, do(t, srv, "PUT", "/integrator", `{"integrator": "euler"}`), http.StatusServiceUnavailable)
+}
+
+func TestUnknownRoute(t *testing.T) {
+	srv, _ := newTestServer(t)
+	if w := do(t, srv, "GET", "/nothing", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("kod %d, oczekiwano 404", w.Code)
+	}
+	if w := do(t, srv, "DELETE", "/state", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("kod %d, oczekiwano 405", w.Code)
+	}
+}
+
+func TestListen(t *testing.T) {
+	for _, addr := range []string{"0.0.0.0:0", ":0", "192.0.2.1:0", "example.com:0", "[::]:0", "127.0.0.1"} {
+		if ln, err := Listen(addr); err == nil {
+			ln.Close()
+			t.Errorf("Listen(%q) przyjął adres spoza pętli zwrotnej", addr)
+		}
+	}
+	ln, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+}
+
+func TestRunner(t *testing.T) {
+	_, runner := newTestServer(t)
+	runner.Tick = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runner.Engine().Snapshot().Step < 10 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Runner nie zlecił kroków (krok %d)", runner.Engine().Snapshot().Step)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	runner.SetPaused(ctx, true)
+	// po wstrzymaniu mogą się jeszcze wykonać kroki zlecone przed nim
+	if !runner.Paused() || runner.Engine().Pending() != 0 {
+		t.Fatalf("po wstrzymaniu: paused %v, zleconych kroków %d", runner.Paused(), runner.Engine().Pending())
+	}
+}
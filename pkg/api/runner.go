@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"gravity-sim/pkg/engine"
+	"gravity-sim/pkg/simulation"
+)
+
+// Runner - Controller dla trybu bez okna: co Tick zleca silnikowi Steps kroków, dopóki
+// symulacja nie jest wstrzymana (odpowiednik pętli klatek GUI)
+type Runner struct {
+	Steps int           // kroki na takt
+	Tick  time.Duration // odstęp taktów
+
+	engine *engine.Engine
+	paused atomic.Bool
+}
+
+// NewRunner tworzy Runner z tempem 1 krok na 1/60 s
+func NewRunner(e *engine.Engine) *Runner {
+	return &Runner{Steps: 1, Tick: time.Second / 60, engine: e}
+}
+
+// Run zleca kroki do anulowania ctx; gdy silnik nie nadąża, kolejne kroki czekają na odrobienie zaległości
+func (r *Runner) Run(ctx context.Context) {
+	tick := time.NewTicker(r.Tick)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+		if !r.paused.Load() && r.engine.Pending() < int64(r.Steps) {
+			r.engine.Advance(r.Steps)
+		}
+	}
+}
+
+func (r *Runner) Engine() *engine.Engine { return r.engine }
+
+func (r *Runner) Edit(cmd simulation.Command) error { return r.engine.Call(cmd.Apply) }
+
+func (r *Runner) Paused() bool { return r.paused.Load() }
+
+// SetPaused wstrzymuje lub wznawia symulację; przy wstrzymaniu porzuca zlecone, niewykonane kroki
+func (r *Runner) SetPaused(_ context.Context, paused bool) error {
+	r.paused.Store(paused)
+	if paused {
+		r.engine.Cancel()
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package assets zawiera sceny wbudowane w program (embed) i wyszukuje sceny po nazwie
+// w katalogach ze zmiennej środowiskowej GRAVITY_SIM_PATH, a potem wśród scen wbudowanych.
+package assets
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// FS - sceny wbudowane w program (pliki *.json z tego katalogu)
+//
+//go:embed *.json
+var FS embed.FS
+
+// PathEnv - zmienna środowiskowa z listą katalogów scen (rozdzielonych jak w PATH)
+const PathEnv = "GRAVITY_SIM_PATH"
+
+// Scene - plik sceny na dysku albo scena wbudowana
+type Scene struct {
+	Name    string // nazwa używana przez -env (nazwa pliku bez .json)
+	Path    string // ścieżka na dysku albo w FS
+	Builtin bool   // scena wbudowana w program
+}
+
+// FromFile zwraca scenę dla dowolnego pliku na dysku (flaga -config)
+func FromFile(p string) Scene {
+	return Scene{Name: strings.TrimSuffix(filepath.Base(p), ".json"), Path: p}
+}
+
+func (s Scene) String() string {
+	if s.Builtin {
+		return "wbudowana:" + s.Path
+	}
+	return s.Path
+}
+
+// Load wczytuje scenę wraz z dołączonymi scenami (dla scen wbudowanych także z FS)
+func (s Scene) Load() (*simulation.Simulator, error) {
+	if s.Builtin {
+		return simulation.LoadConfigFS(FS, s.Path)
+	}
+	return simulation.LoadConfig(s.Path)
+}
+
+// Environment wczytuje spłaszczoną konfigurację sceny (patrz simulation.LoadEnvironment)
+func (s Scene) Environment() (simulation.EnvironmentConfig, error) {
+	if s.Builtin {
+		return simulation.LoadEnvironmentFS(FS, s.Path)
+	}
+	return simulation.LoadEnvironment(s.Path)
+}
+
+// Read wczytuje sam plik sceny, bez dołączonych scen (np. aby poznać jej nazwę)
+func (s Scene) Read() (simulation.EnvironmentConfig, error) {
+	if s.Builtin {
+		return simulation.ReadConfigFS(FS, s.Path)
+	}
+	return simulation.ReadConfig(s.Path)
+}
+
+// Validate sprawdza plik sceny i dołączone sceny (patrz simulation.ValidateFile)
+func (s Scene) Validate() ([]simulation.Issue, error) {
+	if s.Builtin {
+		return simulation.ValidateFS(FS, s.Path)
+	}
+	return simulation.ValidateFile(s.Path)
+}
+
+// SearchPath zwraca katalogi scen z GRAVITY_SIM_PATH w kolejności przeszukiwania
+func SearchPath() []string {
+	var dirs []string
+	for _, d := range filepath.SplitList(os.Getenv(PathEnv)) {
+		if d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+// Find szuka sceny o nazwie name (z rozszerzeniem .json lub bez) najpierw w katalogach
+// ze ścieżki wyszukiwania, a potem wśród scen wbudowanych
+func Find(name string) (Scene, error) {
+	file := strings.TrimSuffix(name, ".json") + ".json"
+	if file != path.Base(file) || file != filepath.Base(file) {
+		return Scene{}, fmt.Errorf("%q nie jest nazwą sceny (dla ścieżki do pliku użyj -config)", name)
+	}
+	for _, dir := range SearchPath() {
+		p := filepath.Join(dir, file)
+		if st, err := os.Stat(p); err == nil && !st.IsDir() {
+			return Scene{Name: strings.TrimSuffix(file, ".json"), Path: p}, nil
+		}
+	}
+	if _, err := fs.Stat(FS, file); err == nil {
+		return Scene{Name: strings.TrimSuffix(file, ".json"), Path: file, Builtin: true}, nil
+	}
+	var names []string
+	if scenes, err := List(); err == nil {
+		for _, s := range scenes {
+			names = append(names, s.Name)
+		}
+	}
+	return Scene{}, fmt.Errorf("nie znaleziono sceny %q (dostępne: %s; katalogi scen można podać w %s)", name, strings.Join(names, ", "), PathEnv)
+}
+
+// List zwraca wszystkie sceny w kolejności przeszukiwania: katalogi ze ścieżki wyszukiwania
+// (każdy posortowany po nazwie), potem sceny wbudowane. Scena może wystąpić kilka razy -
+// Find wybiera pierwszą, a kolejne są przez nią przesłonięte.
+func List() ([]Scene, error) {
+	var scenes []Scene
+	for _, dir := range SearchPath() {
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		for _, p := range files {
+			scenes = append(scenes, Scene{Name: strings.TrimSuffix(filepath.Base(p), ".json"), Path: p})
+		}
+	}
+	files, err := fs.Glob(FS, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range files {
+		scenes = append(scenes, Scene{Name: strings.TrimSuffix(p, ".json"), Path: p, Builtin: true})
+	}
+	return scenes, nil
+}
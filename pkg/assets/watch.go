@@ -0,0 +1,81 @@
+package assets
+
+import (
+	"os"
+	"time"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// DefaultWatchInterval - domyślny odstęp sprawdzania plików sceny
+const DefaultWatchInterval = time.Second
+
+// Watcher wykrywa zmiany plików sceny (wraz z dołączonymi scenami), odpytując ich czas
+// modyfikacji i rozmiar - bez wątków i usług systemowych, wystarczy wołać Poll np. co klatkę.
+// Sceny wbudowane nie mogą się zmienić, więc dla nich Poll zawsze zwraca false.
+type Watcher struct {
+	Interval time.Duration // odstęp między sprawdzeniami (0 = DefaultWatchInterval)
+
+	scene Scene
+	files map[string]fileStamp
+	next  time.Time
+}
+
+// fileStamp - stan pliku w chwili ostatniego sprawdzenia (zero = plik nie istniał)
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+// NewWatcher zaczyna obserwować pliki sceny s w ich bieżącym stanie
+func NewWatcher(s Scene) *Watcher {
+	w := &Watcher{scene: s}
+	w.Reset()
+	return w
+}
+
+// Reset zapamiętuje bieżący stan plików (np. po ponownym wczytaniu sceny) i odświeża
+// ich listę, bo scena mogła zacząć dołączać inne pliki
+func (w *Watcher) Reset() {
+	w.files = make(map[string]fileStamp)
+	if w.scene.Builtin {
+		return
+	}
+	for _, p := range simulation.SceneFiles(w.scene.Path) {
+		w.files[p] = stamp(p)
+	}
+}
+
+// Poll sprawdza pliki (nie częściej niż co Interval) i zwraca true, jeśli od poprzedniego
+// sprawdzenia któryś się zmienił. Każda zmiana jest zgłaszana raz; plik chwilowo usunięty
+// (edytory zapisują często przez zmianę nazwy) zostanie zgłoszony, gdy pojawi się ponownie.
+func (w *Watcher) Poll(now time.Time) bool {
+	if len(w.files) == 0 || now.Before(w.next) {
+		return false
+	}
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w.next = now.Add(interval)
+	changed := false
+	for p, old := range w.files {
+		st := stamp(p)
+		if st == old {
+			continue
+		}
+		w.files[p] = st
+		if st != (fileStamp{}) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func stamp(path string) fileStamp {
+	st, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{mod: st.ModTime(), size: st.Size()}
+}
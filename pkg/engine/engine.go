@@ -0,0 +1,217 @@
+// Package engine uruchamia symulację we własnej gorutynie. Stan jest publikowany jako niezmienne
+// migawki (Snapshot), które inne wątki (renderer, API) czytają bez blokad, a wszystkie zmiany
+// symulatora są wykonywane jako polecenia w wątku silnika, więc nie ma wyścigów danych.
+package engine
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// chunkTime - czas liczenia kroków między publikacjami migawki i obsługą poleceń
+const chunkTime = 5 * time.Millisecond
+
+// ErrStopped - polecenie wysłane do zatrzymanego silnika
+var ErrStopped = errors.New("silnik symulacji jest zatrzymany")
+
+// command - polecenie dla wątku silnika; wynik trafia do res (nil przy Send) dopiero po
+// opublikowaniu migawki ze zmianami
+type command struct {
+	fn  func(*simulation.Simulator) error
+	res chan error
+}
+
+// Engine - symulator liczony w osobnej gorutynie. Kroki zleca Advance, a dostęp do symulatora
+// dają tylko Call i Send: funkcje są wykonywane w wątku silnika między krokami, w kolejności
+// wysłania. Handlery zdarzeń (Events) i obserwatorzy kroków są wołani w wątku silnika.
+type Engine struct {
+	sim    *simulation.Simulator // używany wyłącznie w gorutynie silnika
+	events *simulation.EventBus
+
+	cmds    chan command
+	wake    chan struct{}
+	quit    chan struct{}
+	done    chan struct{}
+	stop    sync.Once
+	pending atomic.Int64
+	snap    atomic.Pointer[Snapshot]
+
+	mu  sync.Mutex
+	err error // pierwszy błąd obserwatora od ostatniego Err
+}
+
+// New publikuje migawkę początkową i uruchamia gorutynę silnika. Od tej chwili symulatora
+// nie wolno używać bezpośrednio - tylko przez Call i Send (do Stop).
+func New(sim *simulation.Simulator) *Engine {
+	e := &Engine{
+		sim:    sim,
+		events: sim.Events,
+		cmds:   make(chan command, 64),
+		wake:   make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	e.snap.Store(newSnapshot(sim))
+	go e.loop()
+	return e
+}
+
+// Snapshot zwraca ostatnią opublikowaną migawkę stanu
+func (e *Engine) Snapshot() *Snapshot {
+	return e.snap.Load()
+}
+
+// Events zwraca szynę zdarzeń symulatora (subskrypcje są bezpieczne z dowolnego wątku,
+// ale handlery działają w wątku silnika)
+func (e *Engine) Events() *simulation.EventBus {
+	return e.events
+}
+
+// Advance zleca wykonanie n kolejnych kroków
+func (e *Engine) Advance(n int) {
+	if n <= 0 {
+		return
+	}
+	e.pending.Add(int64(n))
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pending zwraca liczbę zleconych, jeszcze niewykonanych kroków
+func (e *Engine) Pending() int64 {
+	return e.pending.Load()
+}
+
+// Cancel porzuca zlecone, jeszcze niewykonane kroki
+func (e *Engine) Cancel() {
+	e.pending.Store(0)
+}
+
+// Send wysyła funkcję do wykonania w wątku silnika i nie czeka na nią (po wykonaniu publikowana
+// jest nowa migawka). Przy zatrzymanym silniku funkcja jest pomijana.
+func (e *Engine) Send(fn func(*simulation.Simulator)) {
+	cmd := command{fn: func(sim *simulation.Simulator) error {
+		fn(sim)
+		return nil
+	}}
+	select {
+	case e.cmds <- cmd:
+	case <-e.done:
+	}
+}
+
+// Call wykonuje fn w wątku silnika i czeka na wynik; po powrocie Snapshot uwzględnia zmiany.
+// Dopóki Call czeka, fn może bezpiecznie używać danych wołającego. Nie wolno wołać Call
+// z wnętrza polecenia, handlera zdarzeń ani obserwatora (zakleszczenie).
+func (e *Engine) Call(fn func(*simulation.Simulator) error) error {
+	res := make(chan error, 1)
+	select {
+	case e.cmds <- command{fn: fn, res: res}:
+	case <-e.done:
+		return ErrStopped
+	}
+	select {
+	case err := <-res:
+		return err
+	case <-e.done:
+		// silnik zatrzymany przed wykonaniem polecenia
+		return ErrStopped
+	}
+}
+
+// Err zwraca pierwszy błąd obserwatora od poprzedniego wywołania Err (patrz Simulator.Err)
+func (e *Engine) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	err := e.err
+	e.err = nil
+	return err
+}
+
+// Stop porzuca niewykonane kroki i polecenia, czeka na zakończenie gorutyny silnika i zwraca
+// symulator, którego wołający może znów używać bezpośrednio. Kolejne wywołania nic nie robią.
+func (e *Engine) Stop() *simulation.Simulator {
+	e.stop.Do(func() { close(e.quit) })
+	<-e.done
+	return e.sim
+}
+
+func (e *Engine) loop() {
+	defer close(e.done)
+	for {
+		if e.pending.Load() <= 0 {
+			select {
+			case <-e.quit:
+				return
+			case cmd := <-e.cmds:
+				e.exec(cmd)
+			case <-e.wake:
+			}
+			continue
+		}
+		// polecenia mają pierwszeństwo przed kolejną porcją kroków
+		select {
+		case <-e.quit:
+			return
+		case cmd := <-e.cmds:
+			e.exec(cmd)
+		default:
+			e.steps()
+		}
+	}
+}
+
+// exec wykonuje polecenie, publikuje stan po nim i dopiero wtedy oddaje wynik wołającemu Call
+func (e *Engine) exec(cmd command) {
+	err := cmd.fn(e.sim)
+	e.collectErr()
+	e.snap.Store(newSnapshot(e.sim))
+	if cmd.res != nil {
+		cmd.res <- err
+	}
+}
+
+// steps liczy zlecone kroki przez około chunkTime i publikuje migawkę
+func (e *Engine) steps() {
+	deadline := time.Now().Add(chunkTime)
+	for n := 1; e.take(); n++ {
+		e.sim.Update()
+		e.collectErr()
+		// zegar sprawdzany co kilka kroków - w małych scenach krok trwa krócej niż time.Now
+		if n%8 == 0 && time.Now().After(deadline) {
+			break
+		}
+	}
+	e.snap.Store(newSnapshot(e.sim))
+}
+
+// take zdejmuje jeden krok z licznika zleconych (false, gdy nie ma już kroków, np. po Cancel)
+func (e *Engine) take() bool {
+	for {
+		n := e.pending.Load()
+		if n <= 0 {
+			return false
+		}
+		if e.pending.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+func (e *Engine) collectErr() {
+	err := e.sim.Err()
+	if err == nil {
+		return
+	}
+	e.mu.Lock()
+	if e.err == nil {
+		e.err = err
+	}
+	e.mu.Unlock()
+}
@@ -0,0 +1,155 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gravity-sim/pkg/physics"
+	"gravity-sim/pkg/simulation"
+)
+
+func newTestSim() *simulation.Simulator {
+	sim := simulation.NewSimulator(simulation.EnvironmentConfig{
+		Name: "test",
+		Dt:   0.01,
+		Bodies: []simulation.BodyConfig{
+			{Name: "sun", Mass: 1000, Radius: 10, Color: "#ffff00", Locked: true},
+			{Name: "planet", Mass: 1, Pos: [2]float64{100, 0}, Vel: [2]float64{0, 3}, Radius: 2, Color: "#0000ff"},
+		},
+	})
+	sim.EnableHistory(1<<20, 50)
+	return sim
+}
+
+// TestConcurrentEdits - edycje (Call, Send) i odczyt migawek z wielu gorutyn w trakcie liczenia
+// kroków; uruchamiany z -race wykrywa dostęp do symulatora spoza wątku silnika
+func TestConcurrentEdits(t *testing.T) {
+	e := New(newTestSim())
+	defer e.Stop()
+
+	const rounds = 200
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	// zlecanie kroków i odczyt migawek (jak pętla klatek GUI)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var last int64
+		for i := 0; i < rounds; i++ {
+			e.Advance(50)
+			snap := e.Snapshot()
+			if snap.Step < last {
+				errs <- fmt.Errorf("krok migawki cofnął się bez przewijania: %d < %d", snap.Step, last)
+				return
+			}
+			last = snap.Step
+			for j := range snap.Bodies {
+				b := &snap.Bodies[j]
+				if snap.Body(b.ID) != b {
+					errs <- fmt.Errorf("indeks migawki nie zgadza się dla %q", b.ID)
+					return
+				}
+			}
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+	// edycje przez Call i Send (jak API HTTP i historia edycji)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			cmd := &simulation.AddBodyCmd{Body: physics.Body{Mass: 0.1, Radius: 1, Pos: physics.Vec2{X: float64(200 + i)}}}
+			if err := e.Call(cmd.Apply); err != nil {
+				errs <- err
+				return
+			}
+			if e.Snapshot().Body(cmd.Body.ID) == nil {
+				errs <- fmt.Errorf("migawka po Call nie zawiera ciała %q", cmd.Body.ID)
+				return
+			}
+			e.Send(func(sim *simulation.Simulator) { sim.RecordKeyframe() })
+			if err := e.Call(cmd.Undo); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	// odczyt migawek z gorutyny, która nie zleca kroków (jak API GET /state)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds*5; i++ {
+			snap := e.Snapshot()
+			if len(snap.Bodies) < 2 || snap.Body("sun") == nil {
+				errs <- fmt.Errorf("migawka bez ciał sceny (%d ciał)", len(snap.Bodies))
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	sim := e.Stop()
+	if len(sim.Bodies) != 2 {
+		t.Fatalf("po cofnięciu wszystkich edycji jest %d ciał, oczekiwano 2", len(sim.Bodies))
+	}
+	if sim.Step == 0 {
+		t.Fatal("silnik nie wykonał żadnego kroku")
+	}
+}
+
+// TestCancelAndSeek - Cancel i przewijanie historii w trakcie liczenia kroków
+func TestCancelAndSeek(t *testing.T) {
+	e := New(newTestSim())
+	defer e.Stop()
+
+	e.Advance(1 << 30)
+	deadline := time.Now().Add(5 * time.Second)
+	for e.Snapshot().Step < 100 {
+		if time.Now().After(deadline) {
+			t.Fatal("silnik nie liczy kroków")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	e.Cancel()
+	if err := e.Call(func(sim *simulation.Simulator) error { return sim.SeekStep(10) }); err != nil {
+		t.Fatal(err)
+	}
+	snap := e.Snapshot()
+	if snap.Step != 10 {
+		t.Fatalf("krok %d po SeekStep(10)", snap.Step)
+	}
+	if e.Pending() != 0 {
+		t.Fatalf("po Cancel zostało %d zleconych kroków", e.Pending())
+	}
+	if snap.History == nil || !snap.History.OK || snap.History.First > 10 {
+		t.Fatalf("nieoczekiwany zakres historii: %+v", snap.History)
+	}
+}
+
+// TestStop - po Stop polecenia zwracają ErrStopped, a Send i Advance nie blokują
+func TestStop(t *testing.T) {
+	e := New(newTestSim())
+	e.Advance(1 << 30)
+	sim := e.Stop()
+	if sim == nil || e.Stop() != sim {
+		t.Fatal("Stop nie zwraca symulatora")
+	}
+	if err := e.Call(func(*simulation.Simulator) error { return nil }); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Call po Stop: %v, oczekiwano ErrStopped", err)
+	}
+	e.Send(func(*simulation.Simulator) { t.Error("Send po Stop wykonał polecenie") })
+	e.Advance(1)
+	// po Stop symulatora można znów używać bezpośrednio
+	step := sim.Step
+	sim.Update()
+	if sim.Step != step+1 {
+		t.Fatalf("krok %d po Update, oczekiwano %d", sim.Step, step+1)
+	}
+}
@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"gravity-sim/pkg/physics"
+	"gravity-sim/pkg/simulation"
+	"gravity-sim/pkg/units"
+)
+
+// Snapshot - niezmienna migawka stanu symulacji, publikowana przez Engine po porcji kroków
+// i po każdym poleceniu. Migawka jest współdzielona między wątkami: nie wolno jej modyfikować
+// (także ciał w Bodies i ich Tags).
+type Snapshot struct {
+	Name       string
+	Step       int64
+	Time       float64
+	Dt         float64
+	G          float64
+	Units      units.System
+	Integrator string
+	Boundary   *physics.Boundary // kopia (nil = otwarta przestrzeń)
+	Bodies     []physics.Body    // kopia sim.Bodies
+
+	// History - zakres historii do przewijania (nil = historia wyłączona)
+	History *HistoryRange
+
+	ids map[string]int // Body.ID -> indeks w Bodies
+}
+
+// HistoryRange - zakres kroków zapamiętanej historii (patrz simulation.History.Range)
+type HistoryRange struct {
+	First, Last int64
+	OK          bool // false = historia jest jeszcze pusta
+}
+
+// newSnapshot kopiuje stan symulatora; wołana tylko w wątku silnika
+func newSnapshot(sim *simulation.Simulator) *Snapshot {
+	s := &Snapshot{
+		Name:       sim.Name,
+		Step:       sim.Step,
+		Time:       sim.Time,
+		Dt:         sim.Dt,
+		G:          sim.G,
+		Units:      sim.Units,
+		Integrator: sim.Integrator,
+		Bodies:     append([]physics.Body(nil), sim.Bodies...),
+		ids:        make(map[string]int, len(sim.Bodies)),
+	}
+	if sim.Boundary != nil {
+		b := *sim.Boundary
+		s.Boundary = &b
+	}
+	if sim.History != nil {
+		first, last, ok := sim.History.Range()
+		s.History = &HistoryRange{First: first, Last: last, OK: ok}
+	}
+	for i, b := range s.Bodies {
+		s.ids[b.ID] = i
+	}
+	return s
+}
+
+// Index zwraca indeks ciała o identyfikatorze id w Bodies (-1, gdy takiego ciała nie ma)
+func (s *Snapshot) Index(id string) int {
+	if i, ok := s.ids[id]; ok {
+		return i
+	}
+	return -1
+}
+
+// Body zwraca ciało o identyfikatorze id (nil, gdy takiego ciała nie ma); ciała nie wolno zmieniać
+func (s *Snapshot) Body(id string) *physics.Body {
+	if i := s.Index(id); i >= 0 {
+		return &s.Bodies[i]
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package ephemeris
+
+// BodyInfo - dane ciała z wbudowanej tabeli
+type BodyInfo struct {
+	Name   string
+	GM     float64 // parametr grawitacyjny [km^3/s^2] (JPL DE440)
+	Color  string  // kolor w scenie (HEX)
+	Radius float64 // promień rysowanego ciała w scenie
+}
+
+// Bodies - wbudowana tabela ciał Układu Słonecznego według kodów Horizons.
+// Masy liczone są z GM, bo GM jest znane znacznie dokładniej niż G i masa osobno.
+var Bodies = map[int]BodyInfo{
+	10:  {"Sun", 1.3271244004193938e11, "#ffff00", 20},
+	1:   {"Mercury Barycenter", 2.2031780e4, "#b0a090", 3},
+	199: {"Mercury", 2.2031780e4, "#b0a090", 3},
+	2:   {"Venus Barycenter", 3.24858592e5, "#e8c070", 5},
+	299: {"Venus", 3.24858592e5, "#e8c070", 5},
+	3:   {"Earth-Moon Barycenter", 4.03503235502e5, "#1e90ff", 5},
+	399: {"Earth", 3.98600435436e5, "#1e90ff", 5},
+	301: {"Moon", 4.902800066e3, "#c0c0c0", 2},
+	4:   {"Mars Barycenter", 4.2828375816e4, "#ff4500", 4},
+	499: {"Mars", 4.2828375214e4, "#ff4500", 4},
+	5:   {"Jupiter Barycenter", 1.26712764800e8, "#d2b48c", 12},
+	599: {"Jupiter", 1.26686531900e8, "#d2b48c", 12},
+	6:   {"Saturn Barycenter", 3.7940585200e7, "#f4d03f", 10},
+	699: {"Saturn", 3.7931206234e7, "#f4d03f", 10},
+	7:   {"Uranus Barycenter", 5.794548600e6, "#7fffd4", 8},
+	799: {"Uranus", 5.793951256e6, "#7fffd4", 8},
+	8:   {"Neptune Barycenter", 6.836527100580e6, "#4169e1", 8},
+	899: {"Neptune", 6.835099970e6, "#4169e1", 8},
+	9:   {"Pluto Barycenter", 9.755e2, "#deb887", 2},
+	999: {"Pluto", 8.696138177e2, "#deb887", 2},
+}
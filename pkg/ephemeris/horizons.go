@@ -0,0 +1,220 @@
+// Package ephemeris importuje wektory stanu z plików tabel wektorowych
+// JPL Horizons (wynik tekstowy lub CSV) do konfiguracji sceny.
+package ephemeris
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Jednostki plików Horizons
+const (
+	AU  = 1.495978707e11 // jednostka astronomiczna [m]
+	Day = 86400.0        // doba [s]
+)
+
+// State - wektor stanu ciała z pliku Horizons (w SI, układ współrzędnych pliku)
+type State struct {
+	Name     string
+	ID       int
+	Center   string
+	CenterID int
+	JD       float64    // epoka (JDTDB)
+	Pos      [3]float64 // [m]
+	Vel      [3]float64 // [m/s]
+}
+
+var (
+	targetRe = regexp.MustCompile(`Target body name:\s*(.*?)\s*\((-?\d+)\)`)
+	centerRe = regexp.MustCompile(`Center body name:\s*(.*?)\s*\((-?\d+)\)`)
+	unitsRe  = regexp.MustCompile(`Output units\s*:\s*([A-Z]+-[A-Z]+)`)
+	// wartości w formacie tekstowym: " X =-1.43E+08 Y = 3.39E+07 Z =-1.43E+03"
+	fieldRe = regexp.MustCompile(`\b(X|Y|Z|VX|VY|VZ)\s*=\s*([-+]?[0-9.]+(?:[Ee][-+]?\d+)?)`)
+	jdRe    = regexp.MustCompile(`^\s*([0-9]+\.[0-9]+)\s*=`)
+)
+
+// unitScale zwraca mnożniki zamieniające jednostki pliku na m i m/s
+func unitScale(units string) (length, velocity float64, err error) {
+	switch units {
+	case "KM-S":
+		return 1e3, 1e3, nil
+	case "KM-D":
+		return 1e3, 1e3 / Day, nil
+	case "AU-D":
+		return AU, AU / Day, nil
+	}
+	return 0, 0, fmt.Errorf("nieobsługiwane jednostki %q (KM-S, KM-D, AU-D)", units)
+}
+
+// ReadFile wczytuje plik Horizons; epoch = 0 wybiera pierwszy wiersz, inaczej najbliższy epoce (JD)
+func ReadFile(path string, epoch float64) (State, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return State{}, err
+	}
+	defer f.Close()
+	st, err := Parse(f, epoch)
+	if err != nil {
+		return st, fmt.Errorf("%s: %w", path, err)
+	}
+	return st, nil
+}
+
+// Parse czyta tabelę wektorową Horizons (tekstową lub CSV) i zwraca stan w wybranej epoce
+func Parse(r io.Reader, epoch float64) (State, error) {
+	var st State
+	var units string
+	var columns []string // nagłówek kolumn CSV
+	var rows []State
+	inData := false
+	var cur *State // bieżący rekord w formacie tekstowym
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := sc.Text()
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case trimmed == "$$SOE":
+			inData = true
+			continue
+		case trimmed == "$$EOE":
+			inData = false
+			continue
+		}
+		if !inData {
+			if m := targetRe.FindStringSubmatch(text); m != nil {
+				st.Name = m[1]
+				st.ID, _ = strconv.Atoi(m[2])
+			}
+			if m := centerRe.FindStringSubmatch(text); m != nil {
+				st.Center = m[1]
+				st.CenterID, _ = strconv.Atoi(m[2])
+			}
+			if m := unitsRe.FindStringSubmatch(text); m != nil {
+				units = m[1]
+			}
+			if strings.Contains(trimmed, "JDTDB") && strings.Contains(trimmed, ",") {
+				columns = splitCSV(trimmed)
+			}
+			continue
+		}
+		if trimmed == "" {
+			continue
+		}
+		if columns != nil {
+			row, err := parseCSVRow(columns, splitCSV(trimmed))
+			if err != nil {
+				return st, fmt.Errorf("linia %d: %v", line, err)
+			}
+			rows = append(rows, row)
+			continue
+		}
+		// format tekstowy: wiersz z epoką, potem wiersze X/Y/Z i VX/VY/VZ
+		if m := jdRe.FindStringSubmatch(text); m != nil {
+			jd, _ := strconv.ParseFloat(m[1], 64)
+			rows = append(rows, State{JD: jd})
+			cur = &rows[len(rows)-1]
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		for _, m := range fieldRe.FindAllStringSubmatch(text, -1) {
+			v, err := strconv.ParseFloat(m[2], 64)
+			if err != nil {
+				return st, fmt.Errorf("linia %d: %v", line, err)
+			}
+			setField(cur, m[1], v)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return st, err
+	}
+	if len(rows) == 0 {
+		return st, errors.New("brak danych między $$SOE i $$EOE (czy to tabela wektorowa Horizons?)")
+	}
+	if units == "" {
+		return st, errors.New("brak linii \"Output units\" w nagłówku")
+	}
+	lscale, vscale, err := unitScale(units)
+	if err != nil {
+		return st, err
+	}
+
+	row := rows[0]
+	if epoch != 0 {
+		for _, r := range rows[1:] {
+			if math.Abs(r.JD-epoch) < math.Abs(row.JD-epoch) {
+				row = r
+			}
+		}
+	}
+	st.JD = row.JD
+	for k := 0; k < 3; k++ {
+		st.Pos[k] = row.Pos[k] * lscale
+		st.Vel[k] = row.Vel[k] * vscale
+	}
+	return st, nil
+}
+
+func splitCSV(line string) []string {
+	parts := strings.Split(strings.TrimSuffix(line, ","), ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+func parseCSVRow(columns, values []string) (State, error) {
+	var row State
+	seen := 0
+	for i, name := range columns {
+		if i >= len(values) {
+			break
+		}
+		switch name {
+		case "JDTDB", "X", "Y", "Z", "VX", "VY", "VZ":
+			v, err := strconv.ParseFloat(values[i], 64)
+			if err != nil {
+				return row, fmt.Errorf("kolumna %s: %v", name, err)
+			}
+			if name == "JDTDB" {
+				row.JD = v
+			} else {
+				setField(&row, name, v)
+			}
+			seen++
+		}
+	}
+	if seen < 7 {
+		return row, errors.New("brak kolumn JDTDB, X, Y, Z, VX, VY, VZ")
+	}
+	return row, nil
+}
+
+func setField(s *State, name string, v float64) {
+	switch name {
+	case "X":
+		s.Pos[0] = v
+	case "Y":
+		s.Pos[1] = v
+	case "Z":
+		s.Pos[2] = v
+	case "VX":
+		s.Vel[0] = v
+	case "VY":
+		s.Vel[1] = v
+	case "VZ":
+		s.Vel[2] = v
+	}
+}
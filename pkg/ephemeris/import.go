@@ -0,0 +1,133 @@
+package ephemeris
+
+import (
+	"fmt"
+	"math"
+
+	"gravity-sim/pkg/simulation"
+	"gravity-sim/pkg/units"
+)
+
+// Domyślny układ jednostek sceny: długość 0.01 AU (Ziemia 100 jednostek od Słońca,
+// jak w solar.json), masa Słońca, czas 1 doba
+const (
+	DefaultLength = "0.01 AU"
+	DefaultMass   = "Msun"
+	DefaultTime   = "day"
+	DefaultDt     = 0.1
+)
+
+// Options - ustawienia importu
+type Options struct {
+	Name       string
+	Units      simulation.UnitsConfig // jednostki sceny (puste pola = Default*)
+	Dt         float64                // krok czasowy w jednostkach sceny (0 = DefaultDt)
+	AddCenter  bool                   // dodaj ciało centralne w początku układu, jeśli jest w tabeli i nie ma go wśród plików
+	Barycenter bool                   // przenieś scenę do układu środka masy
+}
+
+// Import zamienia wektory stanu na konfigurację sceny z blokiem units (G wynika z jednostek).
+// Z jest pomijane (rzut na płaszczyznę odniesienia pliku, domyślnie ekliptykę). Masy, kolory
+// i promienie pochodzą z tabeli Bodies; ciała spoza tabeli dostają masę 0 (ostrzeżenie).
+func Import(states []State, opts Options) (simulation.EnvironmentConfig, []string, error) {
+	var env simulation.EnvironmentConfig
+	var warnings []string
+	if len(states) == 0 {
+		return env, nil, fmt.Errorf("brak wektorów stanu do importu")
+	}
+	u := opts.Units
+	if u.Length == "" {
+		u.Length = DefaultLength
+	}
+	if u.Mass == "" {
+		u.Mass = DefaultMass
+	}
+	if u.Time == "" {
+		u.Time = DefaultTime
+	}
+	if opts.Dt <= 0 {
+		opts.Dt = DefaultDt
+	}
+	sys, err := units.New(u.Length, u.Mass, u.Time)
+	if err != nil {
+		return env, nil, err
+	}
+	lengthUnit, massUnit := sys.Length.SI, sys.Mass.SI
+	velUnit := lengthUnit / sys.Time.SI
+
+	ref := states[0]
+	for _, st := range states[1:] {
+		if st.CenterID != ref.CenterID {
+			return env, nil, fmt.Errorf("%s: inne ciało centralne (%s) niż %s (%s)", st.Name, st.Center, ref.Name, ref.Center)
+		}
+		// pliki muszą opisywać ten sam moment (tolerancja ~1 s)
+		if math.Abs(st.JD-ref.JD) > 1e-5 {
+			return env, nil, fmt.Errorf("%s: epoka JD %.6f różni się od %s (JD %.6f)", st.Name, st.JD, ref.Name, ref.JD)
+		}
+	}
+
+	env.Name = opts.Name
+	if env.Name == "" {
+		env.Name = fmt.Sprintf("Horizons JD %.1f", ref.JD)
+	}
+	env.Dt = opts.Dt
+	env.Units = &u
+
+	if opts.AddCenter {
+		present := false
+		for _, st := range states {
+			present = present || st.ID == ref.CenterID
+		}
+		if info, ok := Bodies[ref.CenterID]; ok && !present {
+			env.Bodies = append(env.Bodies, simulation.BodyConfig{
+				Name:   info.Name,
+				Mass:   info.GM * 1e9 / units.GSI / massUnit,
+				Color:  info.Color,
+				Radius: info.Radius,
+			})
+		}
+	}
+	for _, st := range states {
+		b := simulation.BodyConfig{
+			Name:   st.Name,
+			Pos:    [2]float64{st.Pos[0] / lengthUnit, st.Pos[1] / lengthUnit},
+			Vel:    [2]float64{st.Vel[0] / velUnit, st.Vel[1] / velUnit},
+			Color:  "#c8c8ff",
+			Radius: 2,
+		}
+		if info, ok := Bodies[st.ID]; ok {
+			b.Name = info.Name
+			b.Mass = info.GM * 1e9 / units.GSI / massUnit
+			b.Color = info.Color
+			b.Radius = info.Radius
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s (%d): brak w tabeli mas, przyjęto masę 0", st.Name, st.ID))
+		}
+		env.Bodies = append(env.Bodies, b)
+	}
+	if opts.Barycenter {
+		toBarycenter(env.Bodies)
+	}
+	return env, warnings, nil
+}
+
+// toBarycenter przesuwa ciała do układu środka masy (zerowy pęd całkowity)
+func toBarycenter(bodies []simulation.BodyConfig) {
+	var m, px, py, vx, vy float64
+	for _, b := range bodies {
+		m += b.Mass
+		px += b.Mass * b.Pos[0]
+		py += b.Mass * b.Pos[1]
+		vx += b.Mass * b.Vel[0]
+		vy += b.Mass * b.Vel[1]
+	}
+	if m == 0 {
+		return
+	}
+	for i := range bodies {
+		bodies[i].Pos[0] -= px / m
+		bodies[i].Pos[1] -= py / m
+		bodies[i].Vel[0] -= vx / m
+		bodies[i].Vel[1] -= vy / m
+	}
+}
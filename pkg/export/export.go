@@ -0,0 +1,112 @@
+// Package export zapisuje stan symulacji w formatach programów do wizualizacji:
+// legacy VTK (polydata, ParaView) i extended XYZ (OVITO, ASE). Każde ciało jest
+// punktem z atrybutami: masa, promień, prędkość i kolor.
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// Format - format eksportu
+type Format string
+
+const (
+	VTK Format = "vtk"
+	XYZ Format = "xyz"
+)
+
+// ParseFormat zamienia nazwę formatu (lub rozszerzenie pliku) na Format
+func ParseFormat(name string) (Format, error) {
+	switch strings.TrimPrefix(strings.ToLower(name), ".") {
+	case "vtk":
+		return VTK, nil
+	case "xyz", "extxyz":
+		return XYZ, nil
+	}
+	return "", fmt.Errorf("nieznany format eksportu %q (vtk, xyz)", name)
+}
+
+// Snapshot zapisuje bieżący stan do pliku; format jest brany z rozszerzenia, gdy f jest puste
+func Snapshot(path string, f Format, s *simulation.Simulator) error {
+	if f == "" {
+		var err error
+		if f, err = ParseFormat(filepath.Ext(path)); err != nil {
+			return err
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	switch f {
+	case VTK:
+		err = WriteVTK(w, s)
+	case XYZ:
+		err = WriteXYZ(w, s)
+	default:
+		err = fmt.Errorf("nieznany format eksportu %q", f)
+	}
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Series zapisuje serię czasową: dla VTK osobny plik na klatkę
+// (<prefix>_<krok>.vtk, ParaView grupuje je jako jedną serię),
+// dla extended XYZ wszystkie klatki w jednym pliku <prefix>.xyz.
+// Spełnia interfejs trajectory.Writer, więc działa z trajectory.Recorder.
+type Series struct {
+	Prefix string
+	Format Format
+
+	file *os.File
+	w    *bufio.Writer
+}
+
+// NewSeries tworzy serię o podanym prefiksie ścieżki
+func NewSeries(prefix string, f Format) (*Series, error) {
+	if _, err := ParseFormat(string(f)); err != nil {
+		return nil, err
+	}
+	return &Series{Prefix: prefix, Format: f}, nil
+}
+
+// WriteFrame zapisuje bieżący stan jako kolejną klatkę serii
+func (sr *Series) WriteFrame(s *simulation.Simulator) error {
+	if sr.Format == VTK {
+		return Snapshot(fmt.Sprintf("%s_%08d.vtk", sr.Prefix, s.Step), VTK, s)
+	}
+	if sr.file == nil {
+		f, err := os.Create(sr.Prefix + ".xyz")
+		if err != nil {
+			return err
+		}
+		sr.file = f
+		sr.w = bufio.NewWriter(f)
+	}
+	return WriteXYZ(sr.w, s)
+}
+
+// Close zamyka plik serii (dla XYZ)
+func (sr *Series) Close() error {
+	if sr.file == nil {
+		return nil
+	}
+	err := sr.w.Flush()
+	if cerr := sr.file.Close(); err == nil {
+		err = cerr
+	}
+	sr.file = nil
+	return err
+}
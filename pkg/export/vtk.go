@@ -0,0 +1,102 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// WriteVTK zapisuje stan jako legacy VTK (ASCII, DATASET POLYDATA): ciała to punkty
+// z komórkami VERTICES, czas i krok są w FIELD (TIME, CYCLE), a atrybuty punktów to
+// mass, radius, velocity (wektor), color (RGBA 0..1), locked i anti (0/1) oraz tablica
+// tekstowa id z identyfikatorami ciał (Body.ID).
+// Układ jest 2D, więc współrzędna Z jest zawsze 0.
+func WriteVTK(w io.Writer, s *simulation.Simulator) error {
+	bw := bufio.NewWriter(w)
+	n := len(s.Bodies)
+	fmt.Fprintln(bw, "# vtk DataFile Version 3.0")
+	fmt.Fprintf(bw, "gravity-sim %s step %d time %g\n", s.Name, s.Step, s.Time)
+	fmt.Fprintln(bw, "ASCII")
+	fmt.Fprintln(bw, "DATASET POLYDATA")
+	fmt.Fprintln(bw, "FIELD FieldData 2")
+	fmt.Fprintln(bw, "TIME 1 1 double")
+	fmt.Fprintf(bw, "%s\n", num(s.Time))
+	fmt.Fprintln(bw, "CYCLE 1 1 int")
+	fmt.Fprintf(bw, "%d\n", s.Step)
+
+	fmt.Fprintf(bw, "POINTS %d double\n", n)
+	for _, b := range s.Bodies {
+		fmt.Fprintf(bw, "%s %s 0\n", num(b.Pos.X), num(b.Pos.Y))
+	}
+	fmt.Fprintf(bw, "VERTICES %d %d\n", n, 2*n)
+	for i := range s.Bodies {
+		fmt.Fprintf(bw, "1 %d\n", i)
+	}
+
+	fmt.Fprintf(bw, "POINT_DATA %d\n", n)
+	fmt.Fprintln(bw, "SCALARS mass double 1")
+	fmt.Fprintln(bw, "LOOKUP_TABLE default")
+	for _, b := range s.Bodies {
+		fmt.Fprintf(bw, "%s\n", num(b.Mass))
+	}
+	fmt.Fprintln(bw, "SCALARS radius double 1")
+	fmt.Fprintln(bw, "LOOKUP_TABLE default")
+	for _, b := range s.Bodies {
+		fmt.Fprintf(bw, "%s\n", num(b.Radius))
+	}
+	fmt.Fprintln(bw, "VECTORS velocity double")
+	for _, b := range s.Bodies {
+		fmt.Fprintf(bw, "%s %s 0\n", num(b.Vel.X), num(b.Vel.Y))
+	}
+	fmt.Fprintln(bw, "COLOR_SCALARS color 4")
+	for _, b := range s.Bodies {
+		c := b.ColorC
+		fmt.Fprintf(bw, "%.4g %.4g %.4g %.4g\n", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, float64(c.A)/255)
+	}
+	fmt.Fprintln(bw, "SCALARS locked int 1")
+	fmt.Fprintln(bw, "LOOKUP_TABLE default")
+	for _, b := range s.Bodies {
+		fmt.Fprintln(bw, boolInt(b.Locked))
+	}
+	fmt.Fprintln(bw, "SCALARS anti int 1")
+	fmt.Fprintln(bw, "LOOKUP_TABLE default")
+	for _, b := range s.Bodies {
+		fmt.Fprintln(bw, boolInt(b.Anti))
+	}
+	fmt.Fprintln(bw, "FIELD FieldData 1")
+	fmt.Fprintf(bw, "id 1 %d string\n", n)
+	for _, b := range s.Bodies {
+		fmt.Fprintln(bw, vtkString(b.ID))
+	}
+	return bw.Flush()
+}
+
+// vtkString koduje tekst tak jak vtkDataWriter: spacje, znaki sterujące, znaki spoza ASCII i '%' jako %XX
+func vtkString(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&sb, "%%%02X", c)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// num formatuje liczbę w najkrótszej postaci odczytywanej bez straty precyzji
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,59 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"gravity-sim/pkg/physics"
+	"gravity-sim/pkg/simulation"
+)
+
+// xyzProperties - opis kolumn extended XYZ (nazwa:typ:liczba składowych)
+const xyzProperties = "species:S:1:pos:R:3:velo:R:3:mass:R:1:radius:R:1:color:R:3:id:S:1"
+
+// WriteXYZ dopisuje klatkę w formacie extended XYZ: liczba ciał, linia komentarza
+// z opisem kolumn (Properties), czasem i krokiem, potem wiersz na ciało; kolumna id
+// to Body.ID (białe znaki zamienione na "_", bo XYZ rozdziela kolumny spacjami).
+// Kolejne klatki w jednym pliku tworzą trajektorię. Dla pudła okresowego
+// zapisywane są Lattice, Origin i pbc; Z jest zawsze 0.
+func WriteXYZ(w io.Writer, s *simulation.Simulator) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%d\n", len(s.Bodies))
+	fmt.Fprintf(bw, "Properties=%s Time=%s Step=%d", xyzProperties, num(s.Time), s.Step)
+	if bd := s.Boundary; bd != nil && bd.Kind == physics.Periodic {
+		size := bd.Size()
+		fmt.Fprintf(bw, ` Lattice="%s 0 0 0 %s 0 0 0 1" Origin="%s %s 0" pbc="T T F"`, num(size.X), num(size.Y), num(bd.Min.X), num(bd.Min.Y))
+	}
+	if !s.Units.IsZero() {
+		fmt.Fprintf(bw, " Units=%q", s.Units.String())
+	}
+	fmt.Fprintf(bw, " Scene=%q\n", s.Name)
+	for _, b := range s.Bodies {
+		c := b.ColorC
+		fmt.Fprintf(bw, "%s %s %s 0 %s %s 0 %s %s %.4g %.4g %.4g %s\n",
+			species(b), num(b.Pos.X), num(b.Pos.Y), num(b.Vel.X), num(b.Vel.Y), num(b.Mass), num(b.Radius),
+			float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, xyzString(b.ID))
+	}
+	return bw.Flush()
+}
+
+// xyzString zamienia białe znaki na "_", aby tekst był jedną kolumną XYZ
+func xyzString(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return strings.Join(strings.Fields(s), "_")
+}
+
+// species - typ cząstki w XYZ: zwykłe, zablokowane i anty-ciała mają osobne typy
+func species(b physics.Body) string {
+	switch {
+	case b.Anti:
+		return "Anti"
+	case b.Locked:
+		return "Locked"
+	}
+	return "Body"
+}
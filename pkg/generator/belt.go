@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// BeltParams opisuje pas planetoid lub pierścień wokół istniejącego ciała sceny
+type BeltParams struct {
+	Center       int     // indeks ciała centralnego w env.Bodies
+	Count        int     // liczba ciał pasa
+	Inner, Outer float64 // promień wewnętrzny i zewnętrzny (równe = cienki pierścień)
+	MassMin      float64
+	MassMax      float64
+	Eccentricity float64 // rozrzut prędkości jako ułamek prędkości kołowej
+	Retrograde   bool
+	BodyRadius   float64
+	Color        string
+	Seed         uint64
+}
+
+func (p *BeltParams) defaults() {
+	if p.Count <= 0 {
+		p.Count = 100
+	}
+	if p.MassMin <= 0 {
+		p.MassMin = 0.01
+	}
+	if p.MassMax < p.MassMin {
+		p.MassMax = p.MassMin
+	}
+	if p.BodyRadius <= 0 {
+		p.BodyRadius = 1.5
+	}
+	if p.Color == "" {
+		p.Color = "#a0a0a0"
+	}
+}
+
+// Belt dopisuje do sceny pas ciał na orbitach kołowych (z opcjonalnym rozrzutem) wokół ciała Center.
+// Prędkości są liczone względem ciała centralnego (z G i softeningiem sceny), więc pas porusza się razem z nim.
+func Belt(env *simulation.EnvironmentConfig, p BeltParams) error {
+	p.defaults()
+	if p.Center < 0 || p.Center >= len(env.Bodies) {
+		return fmt.Errorf("brak ciała centralnego o indeksie %d (scena ma %d ciał)", p.Center, len(env.Bodies))
+	}
+	if p.Inner <= 0 || p.Outer < p.Inner {
+		return fmt.Errorf("niepoprawne promienie pasa: %g..%g", p.Inner, p.Outer)
+	}
+	if env.AutoOrbit {
+		// ustalmy prędkości orbitalne od razu, aby ciało centralne miało już swoją prędkość
+		simulation.SetOrbitalVelocities(env.Bodies, env.G())
+		env.AutoOrbit = false
+	}
+	c := env.Bodies[p.Center]
+	gr := gravity{g: env.G(), eps: env.EffectiveSoftening()}
+	rng := newRand(p.Seed)
+
+	for n := 0; n < p.Count; n++ {
+		// rozkład jednorodny na powierzchni pierścienia
+		r := math.Sqrt(p.Inner*p.Inner + rng.Float64()*(p.Outer*p.Outer-p.Inner*p.Inner))
+		phi := 2 * math.Pi * rng.Float64()
+		dx, dy := r*math.Cos(phi), r*math.Sin(phi)
+		v := gr.vcirc(r, c.Mass)
+		tx, ty := tangent(dx, dy, r, p.Retrograde)
+		vt := v * (1 + p.Eccentricity*rng.NormFloat64())
+		vr := v * p.Eccentricity * rng.NormFloat64()
+		env.Bodies = append(env.Bodies, simulation.BodyConfig{
+			Mass:   p.MassMin + rng.Float64()*(p.MassMax-p.MassMin),
+			Pos:    [2]float64{c.Pos[0] + dx, c.Pos[1] + dy},
+			Vel:    [2]float64{c.Vel[0] + tx*vt + dx/r*vr, c.Vel[1] + ty*vt + dy/r*vr},
+			Color:  p.Color,
+			Radius: p.BodyRadius,
+		})
+	}
+	return nil
+}
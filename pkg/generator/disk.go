@@ -0,0 +1,178 @@
+package generator
+
+import (
+	"math"
+	"sort"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// DiskParams opisuje rotującą galaktykę dyskową z wykładniczym profilem gęstości i centralnym zgrubieniem
+type DiskParams struct {
+	N           int     // liczba gwiazd dysku
+	DiskMass    float64 // masa całkowita dysku
+	ScaleLength float64 // długość skali Rd dysku wykładniczego
+	Cutoff      float64 // maksymalny promień dysku w jednostkach Rd (domyślnie 5)
+
+	BulgeN      int     // liczba gwiazd zgrubienia
+	BulgeMass   float64 // masa zgrubienia
+	BulgeRadius float64 // promień skali (Plummer) zgrubienia
+
+	CentralMass float64 // masa ciała centralnego (0 = brak)
+
+	Dispersion float64 // względna dyspersja prędkości w dysku (domyślnie 0.05)
+	Retrograde bool    // obrót zgodny z ruchem wskazówek zegara
+
+	BodyRadius   float64
+	DiskColor    string
+	BulgeColor   string
+	CentralColor string
+	Seed         uint64
+	Dt           float64 // krok czasowy; 0 = dobrany z okresu obiegu na Rd
+
+	Units     *simulation.UnitsConfig // układ jednostek sceny (nil = jednostki umowne)
+	Softening *float64                // softening sceny (nil = domyślny dla jednostek)
+}
+
+func (p *DiskParams) defaults() {
+	if p.N <= 0 {
+		p.N = 400
+	}
+	if p.DiskMass <= 0 {
+		p.DiskMass = 1e5
+	}
+	if p.ScaleLength <= 0 {
+		p.ScaleLength = 120
+	}
+	if p.Cutoff <= 0 {
+		p.Cutoff = 5
+	}
+	if p.BulgeN < 0 {
+		p.BulgeN = 0
+	}
+	if p.BulgeRadius <= 0 {
+		p.BulgeRadius = p.ScaleLength / 5
+	}
+	if p.Dispersion <= 0 {
+		p.Dispersion = 0.05
+	}
+	if p.BodyRadius <= 0 {
+		p.BodyRadius = 2
+	}
+	if p.DiskColor == "" {
+		p.DiskColor = "#9fc5ff"
+	}
+	if p.BulgeColor == "" {
+		p.BulgeColor = "#ffd27f"
+	}
+	if p.CentralColor == "" {
+		p.CentralColor = "#ffffff"
+	}
+}
+
+// Disk generuje galaktykę dyskową. Gwiazdy dysku mają prędkość kołową wynikającą z masy
+// zawartej wewnątrz ich promienia (przybliżenie monopolowe z softeningiem) z niewielką
+// dyspersją; gwiazdy zgrubienia dostają izotropowe prędkości o dyspersji rzędu v_c.
+func Disk(p DiskParams) simulation.EnvironmentConfig {
+	p.defaults()
+	rng := newRand(p.Seed)
+
+	var bodies []simulation.BodyConfig
+	if p.CentralMass > 0 {
+		bodies = append(bodies, simulation.BodyConfig{
+			Mass:   p.CentralMass,
+			Color:  p.CentralColor,
+			Radius: p.BodyRadius * 4,
+		})
+	}
+	firstStar := len(bodies)
+
+	// dysk: p(R) ∝ R exp(-R/Rd), czyli rozkład Gamma(2, Rd)
+	dm := p.DiskMass / float64(p.N)
+	for n := 0; n < p.N; {
+		r := -p.ScaleLength * math.Log((1-rng.Float64())*(1-rng.Float64()))
+		if r == 0 || r > p.Cutoff*p.ScaleLength {
+			continue
+		}
+		phi := 2 * math.Pi * rng.Float64()
+		bodies = append(bodies, simulation.BodyConfig{
+			Mass:   dm,
+			Pos:    [2]float64{r * math.Cos(phi), r * math.Sin(phi)},
+			Color:  p.DiskColor,
+			Radius: p.BodyRadius,
+		})
+		n++
+	}
+	firstBulge := len(bodies)
+
+	// zgrubienie: rzut sfery Plummera
+	if p.BulgeN > 0 {
+		bm := p.BulgeMass / float64(p.BulgeN)
+		for n := 0; n < p.BulgeN; {
+			x := rng.Float64()
+			if x == 0 {
+				continue
+			}
+			r := p.BulgeRadius / math.Sqrt(math.Pow(x, -2.0/3.0)-1)
+			if r > 10*p.BulgeRadius {
+				continue
+			}
+			px, py := isotropicXY(rng, r)
+			bodies = append(bodies, simulation.BodyConfig{
+				Mass:   bm,
+				Pos:    [2]float64{px, py},
+				Color:  p.BulgeColor,
+				Radius: p.BodyRadius,
+			})
+			n++
+		}
+	}
+
+	// masa zawarta wewnątrz promienia każdego ciała
+	order := make([]int, 0, len(bodies)-firstStar)
+	for i := firstStar; i < len(bodies); i++ {
+		order = append(order, i)
+	}
+	radius := func(i int) float64 { return math.Hypot(bodies[i].Pos[0], bodies[i].Pos[1]) }
+	sort.Slice(order, func(a, b int) bool { return radius(order[a]) < radius(order[b]) })
+	enclosed := make([]float64, len(bodies))
+	menc := p.CentralMass
+	for _, i := range order {
+		enclosed[i] = menc
+		menc += bodies[i].Mass
+	}
+	gr := gravityOf(p.Units, p.Softening)
+
+	for i := firstStar; i < len(bodies); i++ {
+		r := radius(i)
+		vc := gr.vcirc(r, enclosed[i])
+		if i < firstBulge {
+			tx, ty := tangent(bodies[i].Pos[0], bodies[i].Pos[1], r, p.Retrograde)
+			vt := vc * (1 + p.Dispersion*rng.NormFloat64())
+			vr := vc * p.Dispersion * rng.NormFloat64()
+			bodies[i].Vel = [2]float64{
+				tx*vt + bodies[i].Pos[0]/r*vr,
+				ty*vt + bodies[i].Pos[1]/r*vr,
+			}
+		} else {
+			sigma := vc / math.Sqrt2
+			bodies[i].Vel = [2]float64{sigma * rng.NormFloat64(), sigma * rng.NormFloat64()}
+		}
+	}
+	recenter(bodies)
+
+	dt := p.Dt
+	if dt <= 0 {
+		// okres obiegu na promieniu Rd, 500 kroków na obieg
+		rd := p.ScaleLength
+		mrd := p.CentralMass + p.BulgeMass + p.DiskMass*(1-math.Exp(-1)*2)
+		dt = 2 * math.Pi * rd / gr.vcirc(rd, mrd) / 500
+	}
+	return simulation.EnvironmentConfig{
+		Name:      "Disk galaxy",
+		Dt:        dt,
+		Bodies:    bodies,
+		Units:     p.Units,
+		Softening: p.Softening,
+	}
+}
@@ -0,0 +1,99 @@
+// Package generator tworzy proceduralne sceny (gromady, galaktyki, pasy planetoid)
+// w postaci zwykłego simulation.EnvironmentConfig, który można zapisać do JSON
+// i wczytać przez simulation.LoadConfig.
+package generator
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// newRand tworzy deterministyczny generator liczb losowych dla danego ziarna
+func newRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+}
+
+// isotropicXY losuje kierunek izotropowo w 3D i zwraca rzut wektora o długości r na płaszczyznę XY
+func isotropicXY(rng *rand.Rand, r float64) (float64, float64) {
+	z := 2*rng.Float64() - 1
+	phi := 2 * math.Pi * rng.Float64()
+	s := math.Sqrt(1 - z*z)
+	return r * s * math.Cos(phi), r * s * math.Sin(phi)
+}
+
+// recenter przesuwa układ tak, aby środek masy spoczywał w początku układu współrzędnych
+func recenter(bodies []simulation.BodyConfig) {
+	var m, px, py, vx, vy float64
+	for _, b := range bodies {
+		m += b.Mass
+		px += b.Mass * b.Pos[0]
+		py += b.Mass * b.Pos[1]
+		vx += b.Mass * b.Vel[0]
+		vy += b.Mass * b.Vel[1]
+	}
+	if m == 0 {
+		return
+	}
+	for i := range bodies {
+		bodies[i].Pos[0] -= px / m
+		bodies[i].Pos[1] -= py / m
+		bodies[i].Vel[0] -= vx / m
+		bodies[i].Vel[1] -= vy / m
+	}
+}
+
+// gravity - stała grawitacji i softening sceny, dla której liczone są prędkości
+type gravity struct {
+	g, eps float64
+}
+
+// gravityOf zwraca G i softening sceny o danych jednostkach i softeningu (nil = domyślne)
+func gravityOf(units *simulation.UnitsConfig, softening *float64) gravity {
+	env := simulation.EnvironmentConfig{Units: units, Softening: softening}
+	return gravity{g: env.G(), eps: env.EffectiveSoftening()}
+}
+
+// vcirc zwraca prędkość kołową na promieniu r wokół masy m (przybliżenie monopolowe z softeningiem)
+func (gr gravity) vcirc(r, m float64) float64 {
+	return math.Sqrt(gr.g * m * r * r / math.Pow(r*r+gr.eps*gr.eps, 1.5))
+}
+
+// energies liczy energię kinetyczną i potencjalną układu z tym samym G i softeningiem co symulacja
+func energies(bodies []simulation.BodyConfig, gr gravity) (kin, pot float64) {
+	eps2 := gr.eps * gr.eps
+	for i, a := range bodies {
+		kin += 0.5 * a.Mass * (a.Vel[0]*a.Vel[0] + a.Vel[1]*a.Vel[1])
+		for _, b := range bodies[i+1:] {
+			dx := b.Pos[0] - a.Pos[0]
+			dy := b.Pos[1] - a.Pos[1]
+			pot -= gr.g * a.Mass * b.Mass / math.Sqrt(dx*dx+dy*dy+eps2)
+		}
+	}
+	return kin, pot
+}
+
+// virialize skaluje prędkości tak, aby 2K = |W| (równowaga wirialna w płaszczyźnie symulacji)
+func virialize(bodies []simulation.BodyConfig, gr gravity) {
+	kin, pot := energies(bodies, gr)
+	if kin == 0 || pot == 0 {
+		return
+	}
+	f := math.Sqrt(0.5 * math.Abs(pot) / kin)
+	for i := range bodies {
+		bodies[i].Vel[0] *= f
+		bodies[i].Vel[1] *= f
+	}
+}
+
+// tangent zwraca jednostkowy wektor styczny (obrót przeciwny do ruchu wskazówek w układzie matematycznym)
+func tangent(dx, dy, r float64, retrograde bool) (float64, float64) {
+	if r == 0 {
+		return 0, 0
+	}
+	if retrograde {
+		return dy / r, -dx / r
+	}
+	return -dy / r, dx / r
+}
@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"math"
+	"testing"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// TestGravityOfScene - prędkości wygenerowanych scen są liczone z G i softeningiem sceny,
+// a nie z domyślnych wartości jednostek umownych
+func TestGravityOfScene(t *testing.T) {
+	zero := 0.0
+	for _, tc := range []struct {
+		name      string
+		units     *simulation.UnitsConfig
+		softening *float64
+	}{
+		{"umowne", nil, nil},
+		{"umowne bez softeningu", nil, &zero},
+		{"AU/Msun/yr", &simulation.UnitsConfig{Length: "AU", Mass: "Msun", Time: "yr"}, nil},
+		{"henon", &simulation.UnitsConfig{System: "henon"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env := Plummer(PlummerParams{N: 100, Mass: 1, ScaleRadius: 1, Seed: 3, Units: tc.units, Softening: tc.softening})
+			if env.Units != tc.units || env.Softening != tc.softening {
+				t.Fatalf("scena nie zachowuje jednostek i softeningu: %v, %v", env.Units, env.Softening)
+			}
+			gr := gravity{g: env.G(), eps: env.EffectiveSoftening()}
+			if kin, pot := energies(env.Bodies, gr); math.Abs(2*kin/pot+1) > 1e-9 {
+				t.Errorf("Plummer: 2K/W = %g, oczekiwano -1", 2*kin/pot)
+			}
+
+			env = Disk(DiskParams{N: 50, DiskMass: 1, ScaleLength: 1, CentralMass: 1e6, Dispersion: 1e-12,
+				Seed: 3, Units: tc.units, Softening: tc.softening})
+			// ciało centralne dominuje, więc prędkość gwiazd dysku ≈ prędkość kołowa wokół niego
+			for _, b := range env.Bodies[1:] {
+				r := math.Hypot(b.Pos[0]-env.Bodies[0].Pos[0], b.Pos[1]-env.Bodies[0].Pos[1])
+				v := math.Hypot(b.Vel[0]-env.Bodies[0].Vel[0], b.Vel[1]-env.Bodies[0].Vel[1])
+				if want := gr.vcirc(r, 1e6); math.Abs(v/want-1) > 1e-3 {
+					t.Fatalf("Disk: prędkość %g na promieniu %g, oczekiwano %g", v, r, want)
+				}
+			}
+		})
+	}
+}
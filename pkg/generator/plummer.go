@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"math"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// PlummerParams opisuje gromadę gwiazd o profilu Plummera
+type PlummerParams struct {
+	N           int     // liczba ciał
+	Mass        float64 // masa całkowita gromady
+	ScaleRadius float64 // promień skali a
+	Cutoff      float64 // maksymalny promień w jednostkach a (domyślnie 10)
+	BodyRadius  float64 // promień rysowanego ciała
+	Color       string  // kolor HEX
+	Seed        uint64
+	Dt          float64 // krok czasowy; 0 = dobrany z czasu przejścia
+
+	Units     *simulation.UnitsConfig // układ jednostek sceny (nil = jednostki umowne)
+	Softening *float64                // softening sceny (nil = domyślny dla jednostek)
+}
+
+func (p *PlummerParams) defaults() {
+	if p.N <= 0 {
+		p.N = 200
+	}
+	if p.Mass <= 0 {
+		p.Mass = 1e5
+	}
+	if p.ScaleRadius <= 0 {
+		p.ScaleRadius = 100
+	}
+	if p.Cutoff <= 0 {
+		p.Cutoff = 10
+	}
+	if p.BodyRadius <= 0 {
+		p.BodyRadius = 2
+	}
+	if p.Color == "" {
+		p.Color = "#ffe4b5"
+	}
+}
+
+// Plummer generuje gromadę Plummera w równowadze wirialnej.
+// Pozycje i prędkości są losowane w 3D (metoda Aarsetha, Hénona i Wielena) i rzutowane
+// na płaszczyznę XY; prędkości są następnie przeskalowane tak, aby 2K = |W|
+// dla potencjału liczonego w płaszczyźnie symulacji.
+func Plummer(p PlummerParams) simulation.EnvironmentConfig {
+	p.defaults()
+	rng := newRand(p.Seed)
+	gr := gravityOf(p.Units, p.Softening)
+	a := p.ScaleRadius
+	m := p.Mass / float64(p.N)
+	// prędkość charakterystyczna sqrt(GM/a)
+	v0 := math.Sqrt(gr.g * p.Mass / a)
+
+	bodies := make([]simulation.BodyConfig, 0, p.N)
+	for len(bodies) < p.N {
+		// promień z odwróconej dystrybuanty masy M(r) = M r³/(r²+a²)^{3/2}
+		x := rng.Float64()
+		if x == 0 {
+			continue
+		}
+		r := a / math.Sqrt(math.Pow(x, -2.0/3.0)-1)
+		if r > p.Cutoff*a {
+			continue
+		}
+		// prędkość: losowanie q z g(q) = q²(1-q²)^{7/2} metodą odrzucania
+		var q float64
+		for {
+			q = rng.Float64()
+			if 0.1*rng.Float64() < q*q*math.Pow(1-q*q, 3.5) {
+				break
+			}
+		}
+		v := q * math.Sqrt2 * math.Pow(1+r*r/(a*a), -0.25) * v0
+
+		px, py := isotropicXY(rng, r)
+		vx, vy := isotropicXY(rng, v)
+		bodies = append(bodies, simulation.BodyConfig{
+			Mass:   m,
+			Pos:    [2]float64{px, py},
+			Vel:    [2]float64{vx, vy},
+			Color:  p.Color,
+			Radius: p.BodyRadius,
+		})
+	}
+	recenter(bodies)
+	virialize(bodies, gr)
+
+	dt := p.Dt
+	if dt <= 0 {
+		// czas przejścia t = sqrt(a³/GM), 200 kroków na czas przejścia
+		dt = math.Sqrt(a*a*a/(gr.g*p.Mass)) / 200
+	}
+	return simulation.EnvironmentConfig{
+		Name:      "Plummer cluster",
+		Dt:        dt,
+		Bodies:    bodies,
+		Units:     p.Units,
+		Softening: p.Softening,
+	}
+}
@@ -36,6 +36,8 @@ func (v Vec2) Normalize() Vec2 {
 
 // Ciało
 type Body struct {
+	ID     string // stały, unikalny identyfikator (nadaje simulation.Simulator)
+	Name   string // nazwa z pliku sceny (może być pusta)
 	Mass   float64
 	Pos    Vec2
 	Vel    Vec2
@@ -44,6 +46,7 @@ type Body struct {
 	ColorC color.RGBA
 	Locked bool // unieruchomione
 	Anti   bool // antygrawitacja
+	Tags   []string
 }
 
 func (b *Body) Update(dt float64, bodies []Body) {
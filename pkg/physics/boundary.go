@@ -0,0 +1,93 @@
+package physics
+
+import "math"
+
+// BoundaryKind - rodzaj warunku brzegowego
+type BoundaryKind int
+
+const (
+	Open     BoundaryKind = iota // brak ograniczeń
+	Reflect                      // odbijające ściany
+	Periodic                     // okresowe zawijanie (torus)
+)
+
+// Boundary - prostokątny obszar symulacji z warunkami brzegowymi.
+// Wskaźnik nil oznacza otwartą przestrzeń, wszystkie metody są bezpieczne dla nil.
+type Boundary struct {
+	Kind     BoundaryKind
+	Min, Max Vec2
+	// Images - liczba powłok obrazów okresowych sumowanych przy liczeniu siły
+	// (0 = tylko konwencja minimum image). Obcięta suma po sieci obrazów to
+	// proste przybliżenie sumowania Ewalda; koszt rośnie jak (2·Images+1)².
+	Images int
+}
+
+// Size zwraca rozmiary pudła
+func (bd *Boundary) Size() Vec2 {
+	return bd.Max.Sub(bd.Min)
+}
+
+// Separation zwraca wektor od a do b; w pudle okresowym jest to najkrótszy obraz (minimum image)
+func (bd *Boundary) Separation(a, b Vec2) Vec2 {
+	d := b.Sub(a)
+	if bd == nil || bd.Kind != Periodic {
+		return d
+	}
+	l := bd.Size()
+	d.X -= l.X * math.Round(d.X/l.X)
+	d.Y -= l.Y * math.Round(d.Y/l.Y)
+	return d
+}
+
+// Confine stosuje warunek brzegowy do ciała po kroku całkowania
+func (bd *Boundary) Confine(b *Body) {
+	if bd == nil {
+		return
+	}
+	switch bd.Kind {
+	case Periodic:
+		l := bd.Size()
+		b.Pos.X = bd.Min.X + wrap(b.Pos.X-bd.Min.X, l.X)
+		b.Pos.Y = bd.Min.Y + wrap(b.Pos.Y-bd.Min.Y, l.Y)
+	case Reflect:
+		b.Pos.X, b.Vel.X = reflect(b.Pos.X, b.Vel.X, bd.Min.X, bd.Max.X, b.Radius)
+		b.Pos.Y, b.Vel.Y = reflect(b.Pos.Y, b.Vel.Y, bd.Min.Y, bd.Max.Y, b.Radius)
+	}
+}
+
+// Wrapped informuje, czy przejście z a do b nastąpiło przez krawędź pudła okresowego
+// (przydatne, aby nie rysować śladu przez cały ekran)
+func (bd *Boundary) Wrapped(a, b Vec2) bool {
+	if bd == nil || bd.Kind != Periodic {
+		return false
+	}
+	l := bd.Size()
+	d := b.Sub(a)
+	return math.Abs(d.X) > l.X/2 || math.Abs(d.Y) > l.Y/2
+}
+
+func wrap(x, l float64) float64 {
+	x = math.Mod(x, l)
+	if x < 0 {
+		x += l
+	}
+	return x
+}
+
+// reflect odbija współrzędną od ścian [lo, hi], uwzględniając promień ciała, jeśli się mieści
+func reflect(x, v, lo, hi, r float64) (float64, float64) {
+	if hi-lo > 2*r {
+		lo += r
+		hi -= r
+	}
+	if x < lo {
+		x = 2*lo - x
+		v = math.Abs(v)
+	}
+	if x > hi {
+		x = 2*hi - x
+		v = -math.Abs(v)
+	}
+	// przy bardzo dużej prędkości ciało mogłoby wyskoczyć za przeciwległą ścianę
+	return math.Min(math.Max(x, lo), hi), v
+}
@@ -1,10 +1,48 @@
 package physics
 
-const G = 6.67430e-1 // stala grawitacji
+import "math"
+
+const G = 6.67430e-1 // stala grawitacji (jednostki umowne - sceny bez bloku units)
+
+// Softening - parametr softeningu, dostosuj do skali układu
+const Softening = 5.0
+
+// Params - parametry oddziaływania w danej scenie: stała grawitacji w jednostkach sceny,
+// softening i warunki brzegowe. Wskaźnik nil oznacza domyślne G, Softening i otwartą przestrzeń.
+type Params struct {
+	G         float64
+	Softening float64
+	Boundary  *Boundary
+}
+
+// defaultParams - parametry używane, gdy Params jest nil
+var defaultParams = Params{G: G, Softening: Softening}
+
+func (p *Params) get() *Params {
+	if p == nil {
+		return &defaultParams
+	}
+	return p
+}
 
 func ComputeAcceleration(b1 Body, others []Body) Vec2 {
+	return (*Params)(nil).Acceleration(b1, others)
+}
+
+// Acceleration liczy przyspieszenie ciała b1 od pozostałych z uwzględnieniem warunków brzegowych.
+// W pudle okresowym używany jest najbliższy obraz każdego ciała oraz opcjonalnie Boundary.Images powłok obrazów.
+func (p *Params) Acceleration(b1 Body, others []Body) Vec2 {
+	p = p.get()
+	bd := p.Boundary
 	force := Vec2{0, 0}
-	epsilon := 5.0 // parametr softeningu, dostosuj do skali układu
+	epsilon := p.Softening
+
+	images := 0
+	var l Vec2
+	if bd != nil && bd.Kind == Periodic {
+		images = bd.Images
+		l = bd.Size()
+	}
 
 	for _, b2 := range others {
 		// porównujemy adresy przez wartości — jeśli to to samo ciało, pomiń
@@ -12,16 +50,41 @@ func ComputeAcceleration(b1 Body, others []Body) Vec2 {
 			continue
 		}
 
-		dir := b2.Pos.Sub(b1.Pos)
-		d2 := dir.Len()*dir.Len() + epsilon*epsilon // softening
-		fmag := G * b1.Mass * b2.Mass / d2
-		// jeśli b2.Anti -> odpychanie: zmień znak siły
-		if b2.Anti {
-			fmag = -fmag
+		base := bd.Separation(b1.Pos, b2.Pos)
+		for ix := -images; ix <= images; ix++ {
+			for iy := -images; iy <= images; iy++ {
+				dir := base.Add(Vec2{float64(ix) * l.X, float64(iy) * l.Y})
+				d2 := dir.Len()*dir.Len() + epsilon*epsilon // softening
+				if d2 == 0 {
+					// to samo ciało (bez softeningu nie ma od niego siły)
+					continue
+				}
+				fmag := p.G * b1.Mass * b2.Mass / d2
+				// jeśli b2.Anti -> odpychanie: zmień znak siły
+				if b2.Anti {
+					fmag = -fmag
+				}
+				acc := dir.Normalize().Mul(fmag / b1.Mass)
+				force = force.Add(acc)
+			}
 		}
-		acc := dir.Normalize().Mul(fmag / b1.Mass)
-		force = force.Add(acc)
 	}
 
 	return force
 }
+
+// PotentialEnergy zwraca energię potencjalną ciała b1 w polu ciała b2 przy domyślnych parametrach
+func PotentialEnergy(b1, b2 Body) float64 {
+	return (*Params)(nil).PotentialEnergy(b1, b2)
+}
+
+// PotentialEnergy zwraca energię potencjalną ciała b1 w polu ciała b2 (z tym samym softeningiem co siła)
+func (p *Params) PotentialEnergy(b1, b2 Body) float64 {
+	p = p.get()
+	d := p.Boundary.Separation(b1.Pos, b2.Pos).Len()
+	u := -p.G * b1.Mass * b2.Mass / math.Sqrt(d*d+p.Softening*p.Softening)
+	if b2.Anti {
+		u = -u
+	}
+	return u
+}
@@ -2,10 +2,16 @@ package physics
 
 // IntegrateEulerSymplectic wykonuje symulację metodą semi-implicit Euler
 func IntegrateEulerSymplectic(bodies []Body, dt float64) []Body {
+	return IntegrateEulerSymplecticIn(bodies, dt, nil)
+}
+
+// IntegrateEulerSymplecticIn - semi-implicit Euler z parametrami sceny p (nil = domyślne G, softening, otwarta przestrzeń)
+func IntegrateEulerSymplecticIn(bodies []Body, dt float64, p *Params) []Body {
+	bd := p.get().Boundary
 	// Aktualizacja dla każdego ciała
 	for i := range bodies {
 		// Oblicz przyspieszenie na podstawie aktualnych pozycji wszystkich ciał
-		bodies[i].Acc = ComputeAcceleration(bodies[i], bodies)
+		bodies[i].Acc = p.Acceleration(bodies[i], bodies)
 
 		if bodies[i].Locked {
 			// nie aktualizujemy prędkości i pozycji zablokowanego ciała
@@ -18,6 +24,51 @@ func IntegrateEulerSymplectic(bodies []Body, dt float64) []Body {
 
 		// Następnie aktualizujemy pozycję według nowej prędkości
 		bodies[i].Pos = bodies[i].Pos.Add(bodies[i].Vel.Mul(dt))
+
+		// odbicie od ścian lub zawinięcie w pudle okresowym
+		bd.Confine(&bodies[i])
+	}
+	return bodies
+}
+
+// IntegrateLeapfrogIn - metoda leapfrog (kick-drift-kick): pół kroku prędkości, pełny krok pozycji,
+// drugie pół kroku prędkości z nowym przyspieszeniem. W przeciwieństwie do Eulera wszystkie ciała
+// są przesuwane jednocześnie; metoda jest drugiego rzędu i dobrze zachowuje energię.
+func IntegrateLeapfrogIn(bodies []Body, dt float64, p *Params) []Body {
+	bd := p.get().Boundary
+	acc := make([]Vec2, len(bodies))
+	for i := range bodies {
+		acc[i] = p.Acceleration(bodies[i], bodies)
+	}
+	for i := range bodies {
+		if bodies[i].Locked {
+			bodies[i].Vel = Vec2{0, 0}
+			continue
+		}
+		bodies[i].Vel = bodies[i].Vel.Add(acc[i].Mul(dt / 2))
+		bodies[i].Pos = bodies[i].Pos.Add(bodies[i].Vel.Mul(dt))
+		bd.Confine(&bodies[i])
+	}
+	for i := range bodies {
+		acc[i] = p.Acceleration(bodies[i], bodies)
+	}
+	for i := range bodies {
+		bodies[i].Acc = acc[i]
+		if !bodies[i].Locked {
+			bodies[i].Vel = bodies[i].Vel.Add(acc[i].Mul(dt / 2))
+		}
 	}
 	return bodies
 }
+
+// Integrator - metoda wykonująca jeden krok całkowania
+type Integrator func(bodies []Body, dt float64, p *Params) []Body
+
+// DefaultIntegrator - nazwa metody używanej, gdy scena nie wybiera innej
+const DefaultIntegrator = "euler"
+
+// Integrators - dostępne metody całkowania według nazwy
+var Integrators = map[string]Integrator{
+	"euler":    IntegrateEulerSymplecticIn,
+	"leapfrog": IntegrateLeapfrogIn,
+}
@@ -0,0 +1,61 @@
+package simulation
+
+import (
+	"fmt"
+
+	"gravity-sim/pkg/physics"
+)
+
+// BoundaryConfig - warunki brzegowe sceny
+type BoundaryConfig struct {
+	Type   string     `json:"type"`             // "reflect" lub "periodic"
+	Min    [2]float64 `json:"min"`              // lewy górny róg pudła
+	Max    [2]float64 `json:"max"`              // prawy dolny róg pudła
+	Images int        `json:"images,omitempty"` // liczba powłok obrazów okresowych (przybliżenie typu Ewald)
+}
+
+// toPhysics zamienia konfigurację na physics.Boundary
+func (c *BoundaryConfig) toPhysics() (*physics.Boundary, error) {
+	if c == nil {
+		return nil, nil
+	}
+	bd := &physics.Boundary{
+		Min:    physics.Vec2{X: c.Min[0], Y: c.Min[1]},
+		Max:    physics.Vec2{X: c.Max[0], Y: c.Max[1]},
+		Images: c.Images,
+	}
+	switch c.Type {
+	case "reflect":
+		bd.Kind = physics.Reflect
+	case "periodic":
+		bd.Kind = physics.Periodic
+	case "", "open":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("nieznany rodzaj brzegu %q (reflect, periodic)", c.Type)
+	}
+	if bd.Max.X <= bd.Min.X || bd.Max.Y <= bd.Min.Y {
+		return nil, fmt.Errorf("pudło brzegowe musi mieć max > min, jest min=%v max=%v", c.Min, c.Max)
+	}
+	if c.Images < 0 {
+		return nil, fmt.Errorf("liczba obrazów okresowych nie może być ujemna")
+	}
+	return bd, nil
+}
+
+// boundaryConfig zamienia physics.Boundary z powrotem na konfigurację (nil = otwarta przestrzeń)
+func boundaryConfig(bd *physics.Boundary) *BoundaryConfig {
+	if bd == nil || bd.Kind == physics.Open {
+		return nil
+	}
+	c := &BoundaryConfig{
+		Type:   "reflect",
+		Min:    [2]float64{bd.Min.X, bd.Min.Y},
+		Max:    [2]float64{bd.Max.X, bd.Max.Y},
+		Images: bd.Images,
+	}
+	if bd.Kind == physics.Periodic {
+		c.Type = "periodic"
+	}
+	return c
+}
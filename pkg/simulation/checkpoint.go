@@ -0,0 +1,173 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+
+	"gravity-sim/pkg/physics"
+)
+
+// CheckpointVersion - wersja formatu pliku stanu symulacji. Restore czyta także starsze wersje:
+//
+//	1 - stan ciał, czas, dt, metoda całkowania, warunki brzegowe i detektory
+//	2 - dodatkowo identyfikatory i etykiety ciał, jednostki, G i softening
+//	    oraz licznik automatycznych identyfikatorów (next_id)
+const CheckpointVersion = 2
+
+const checkpointFormat = "gravity-sim-checkpoint"
+
+// checkpoint - pełny stan symulatora zapisywany na dysk
+type checkpoint struct {
+	Format     string          `json:"format"`
+	Version    int             `json:"version"`
+	Name       string          `json:"name"`
+	Dt         float64         `json:"dt"`
+	Integrator string          `json:"integrator"`
+	Time       float64         `json:"time"`
+	Step       int64           `json:"step"`
+	Boundary   *BoundaryConfig `json:"boundary,omitempty"`
+	Escape     *EscapeConfig   `json:"escape,omitempty"`
+	Events     *EventsConfig   `json:"events,omitempty"`
+	Units      *UnitsConfig    `json:"units,omitempty"`
+	G          float64         `json:"g,omitempty"` // brak w starszych plikach - wtedy domyślne G i softening
+	Softening  float64         `json:"softening"`
+	NextID     int             `json:"next_id,omitempty"` // numer następnego identyfikatora "#n"
+	Bodies     []bodyState     `json:"bodies"`
+}
+
+// bodyState - stan ciała; kolor zapisujemy jako RGBA, aby nie tracić kanału alfa
+type bodyState struct {
+	ID     string     `json:"id,omitempty"` // brak w starszych plikach - nadawany przy odczycie
+	Name   string     `json:"name,omitempty"`
+	Mass   float64    `json:"mass"`
+	Pos    [2]float64 `json:"pos"`
+	Vel    [2]float64 `json:"vel"`
+	Acc    [2]float64 `json:"acc"`
+	Radius float64    `json:"radius"`
+	Color  [4]uint8   `json:"color"`
+	Locked bool       `json:"locked,omitempty"`
+	Anti   bool       `json:"anti,omitempty"`
+	Tags   []string   `json:"tags,omitempty"`
+}
+
+// Save zapisuje pełny stan symulatora do pliku. Zapis idzie najpierw do pliku tymczasowego,
+// więc przerwany zapis nie niszczy poprzedniego checkpointu.
+func (s *Simulator) Save(path string) error {
+	cp := checkpoint{
+		Format:     checkpointFormat,
+		Version:    CheckpointVersion,
+		Name:       s.Name,
+		Dt:         s.Dt,
+		Integrator: s.Integrator,
+		Time:       s.Time,
+		Step:       s.Step,
+		Boundary:   boundaryConfig(s.Boundary),
+		Escape:     s.Escape,
+		Units:      unitsConfig(s.Units),
+		G:          s.G,
+		Softening:  s.Softening,
+		NextID:     s.nextID,
+		Bodies:     make([]bodyState, len(s.Bodies)),
+	}
+	if s.CloseEncounter > 0 {
+		cp.Events = &EventsConfig{CloseEncounter: s.CloseEncounter}
+	}
+	for i, b := range s.Bodies {
+		cp.Bodies[i] = bodyState{
+			ID:     b.ID,
+			Name:   b.Name,
+			Mass:   b.Mass,
+			Pos:    [2]float64{b.Pos.X, b.Pos.Y},
+			Vel:    [2]float64{b.Vel.X, b.Vel.Y},
+			Acc:    [2]float64{b.Acc.X, b.Acc.Y},
+			Radius: b.Radius,
+			Color:  [4]uint8{b.ColorC.R, b.ColorC.G, b.ColorC.B, b.ColorC.A},
+			Locked: b.Locked,
+			Anti:   b.Anti,
+			Tags:   b.Tags,
+		}
+	}
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("błąd serializacji stanu: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("błąd zapisu stanu: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("błąd zapisu stanu: %v", err)
+	}
+	return nil
+}
+
+// Restore odtwarza symulator z pliku zapisanego przez Simulator.Save
+func Restore(path string) (*Simulator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("błąd odczytu pliku: %v", err)
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("błąd parsowania stanu: %v", err)
+	}
+	if cp.Format != checkpointFormat {
+		return nil, fmt.Errorf("%s nie jest plikiem stanu symulacji", path)
+	}
+	if cp.Version < 1 || cp.Version > CheckpointVersion {
+		return nil, fmt.Errorf("nieobsługiwana wersja pliku stanu %d (obsługiwane 1..%d)", cp.Version, CheckpointVersion)
+	}
+	// plik mógł być edytowany ręcznie: symulator z dt <= 0 nigdy nie posunąłby się w czasie,
+	// a pusta metoda całkowania zostałaby po cichu zastąpiona domyślną
+	if !(cp.Dt > 0) || math.IsInf(cp.Dt, 0) {
+		return nil, fmt.Errorf("dt musi być dodatnie i skończone (%g)", cp.Dt)
+	}
+	if _, ok := physics.Integrators[cp.Integrator]; !ok {
+		return nil, fmt.Errorf("nieznana metoda całkowania %q", cp.Integrator)
+	}
+
+	sim := NewSimulator(EnvironmentConfig{
+		Name:       cp.Name,
+		Dt:         cp.Dt,
+		Integrator: cp.Integrator,
+		Escape:     cp.Escape,
+		Events:     cp.Events,
+	})
+	if sim.Units, err = cp.Units.toUnits(); err != nil {
+		return nil, fmt.Errorf("units: %v", err)
+	}
+	if cp.G != 0 {
+		sim.G, sim.Softening = cp.G, cp.Softening
+	}
+	sim.Time = cp.Time
+	sim.Step = cp.Step
+	if sim.Boundary, err = cp.Boundary.toPhysics(); err != nil {
+		return nil, fmt.Errorf("boundary: %v", err)
+	}
+	sim.Bodies = make([]physics.Body, len(cp.Bodies))
+	for i, b := range cp.Bodies {
+		sim.Bodies[i] = physics.Body{
+			ID:     b.ID,
+			Name:   b.Name,
+			Mass:   b.Mass,
+			Pos:    physics.Vec2{X: b.Pos[0], Y: b.Pos[1]},
+			Vel:    physics.Vec2{X: b.Vel[0], Y: b.Vel[1]},
+			Acc:    physics.Vec2{X: b.Acc[0], Y: b.Acc[1]},
+			Radius: b.Radius,
+			ColorC: color.RGBA{b.Color[0], b.Color[1], b.Color[2], b.Color[3]},
+			Locked: b.Locked,
+			Anti:   b.Anti,
+			Tags:   b.Tags,
+		}
+	}
+	// identyfikatory ciał usuniętych przed zapisem nie mogą zostać nadane ponownie
+	// (dziennik zdarzeń i ślady są indeksowane po ID); starsze pliki nie mają licznika,
+	// wtedy assignIDs przesuwa go za wszystkie istniejące "#n"
+	sim.nextID = max(sim.nextID, cp.NextID)
+	sim.assignIDs()
+	return sim, nil
+}
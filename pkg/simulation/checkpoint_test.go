@@ -0,0 +1,85 @@
+package simulation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gravity-sim/pkg/physics"
+)
+
+// TestCheckpointNextID - po odtworzeniu stanu nowe ciała nie dostają identyfikatorów
+// ciał usuniętych przed zapisem, także w plikach bez licznika (wersja 1)
+func TestCheckpointNextID(t *testing.T) {
+	sim := NewSimulator(EnvironmentConfig{
+		Name: "test",
+		Dt:   0.01,
+		Bodies: []BodyConfig{
+			{Mass: 1, Radius: 1},
+			{Mass: 1, Pos: [2]float64{10, 0}, Radius: 1},
+		},
+	})
+	var removed []string
+	for range 3 {
+		id, err := sim.AddBody(physics.Body{Mass: 1, Radius: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		removed = append(removed, id)
+	}
+	// zostaje tylko ciało z najwyższym numerem - licznik odtworzony z "#n" go nie przekroczy
+	sim.RemoveBody(sim.Index(removed[0]))
+	sim.RemoveBody(sim.Index(removed[1]))
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := sim.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	want, err := sim.AddBody(physics.Body{Mass: 1, Radius: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		edit  func(cp map[string]any)
+		exact bool // licznik zapisany w pliku - ten sam identyfikator co w symulatorze źródłowym
+	}{
+		{"v2", func(map[string]any) {}, true},
+		{"bez next_id", func(cp map[string]any) { delete(cp, "next_id"); cp["version"] = 1 }, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var cp map[string]any
+			if err := json.Unmarshal(data, &cp); err != nil {
+				t.Fatal(err)
+			}
+			tc.edit(cp)
+			data, _ = json.Marshal(cp)
+			edited := filepath.Join(t.TempDir(), "state.json")
+			if err := os.WriteFile(edited, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			restored, err := Restore(edited)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, err := restored.AddBody(physics.Body{Mass: 1, Radius: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.exact && id != want {
+				t.Fatalf("nowe ciało dostało %q, oczekiwano %q", id, want)
+			}
+			for _, old := range removed {
+				if id == old {
+					t.Fatalf("nowe ciało dostało identyfikator %q usuniętego ciała", id)
+				}
+			}
+		})
+	}
+}
@@ -3,10 +3,13 @@ package simulation
 import (
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"math"
 	"os"
 
 	"image/color"
+
+	"gravity-sim/pkg/physics"
 )
 
 // --- Struktura konfiguracji środowiska ---
@@ -15,22 +18,67 @@ type EnvironmentConfig struct {
 	Dt        float64      `json:"dt"`
 	Bodies    []BodyConfig `json:"bodies"`
 	AutoOrbit bool         `json:"auto_orbit,omitempty"`
+
+	Integrator string           `json:"integrator,omitempty"` // "euler" (domyślnie) lub "leapfrog"
+	Includes   []IncludeConfig  `json:"includes,omitempty"`
+	Templates  []TemplateConfig `json:"templates,omitempty"` // pierścienie, siatki i losowe rozkłady ciał
+	Escape     *EscapeConfig    `json:"escape,omitempty"`
+	Boundary   *BoundaryConfig  `json:"boundary,omitempty"`
+	Events     *EventsConfig    `json:"events,omitempty"`
+	Units      *UnitsConfig     `json:"units,omitempty"`
+	Softening  *float64         `json:"softening,omitempty"` // domyślnie physics.Softening, a przy bloku units 0
+	Schema     string           `json:"$schema,omitempty"`   // ścieżka do schematu JSON (dla edytorów)
 }
 
 type BodyConfig struct {
+	Name   string     `json:"name,omitempty"`
 	Mass   float64    `json:"mass"`
 	Pos    [2]float64 `json:"pos"`
 	Vel    [2]float64 `json:"vel"`
 	Color  string     `json:"color"`
-	Radius float64
+	Radius float64    `json:"radius"`
+	Locked bool       `json:"locked,omitempty"` // unieruchomione
+	Anti   bool       `json:"anti,omitempty"`   // antygrawitacja
+	Tags   []string   `json:"tags,omitempty"`   // dowolne etykiety, np. "planet", "moon"
+}
+
+// Body tworzy ciało symulacji z konfiguracji (bez ID - nadaje je Simulator)
+func (b BodyConfig) Body() physics.Body {
+	return physics.Body{
+		Name:   b.Name,
+		Mass:   b.Mass,
+		Pos:    physics.Vec2{X: b.Pos[0], Y: b.Pos[1]},
+		Vel:    physics.Vec2{X: b.Vel[0], Y: b.Vel[1]},
+		Radius: b.Radius,
+		ColorC: parseColor(b.Color),
+		Locked: b.Locked,
+		Anti:   b.Anti,
+		Tags:   b.Tags,
+	}
+}
+
+// BodyConfigOf zwraca konfigurację ciała, np. do odpowiedzi API (kolor bez kanału alfa)
+func BodyConfigOf(b physics.Body) BodyConfig {
+	return BodyConfig{
+		Name:   b.Name,
+		Mass:   b.Mass,
+		Pos:    [2]float64{b.Pos.X, b.Pos.Y},
+		Vel:    [2]float64{b.Vel.X, b.Vel.Y},
+		Color:  FormatColor(b.ColorC),
+		Radius: b.Radius,
+		Locked: b.Locked,
+		Anti:   b.Anti,
+		Tags:   b.Tags,
+	}
 }
 
-func SetOrbitalVelocities(bodies []BodyConfig) {
+// SetOrbitalVelocities nadaje ciałom bez prędkości prędkość orbity kołowej wokół pierwszego ciała;
+// G to stała grawitacji w jednostkach sceny (patrz EnvironmentConfig.G)
+func SetOrbitalVelocities(bodies []BodyConfig, G float64) {
 	if len(bodies) == 0 {
 		return
 	}
 	central := bodies[0] // pierwsze ciało traktujemy jako centralne
-	G := 6.67430e-1
 	for i := 1; i < len(bodies); i++ {
 		b := (bodies[i].Vel[0] == 0) && bodies[i].Vel[1] == 0
 		if !b {
@@ -49,32 +97,128 @@ func SetOrbitalVelocities(bodies []BodyConfig) {
 
 // --- Wczytanie pliku konfiguracyjnego ---
 func LoadConfig(path string) (*Simulator, error) {
-	data, err := os.ReadFile(path)
+	return loadConfig(source{}, path)
+}
+
+// LoadConfigFS wczytuje scenę z fsys (np. embed.FS ze scenami wbudowanymi w program)
+func LoadConfigFS(fsys fs.FS, name string) (*Simulator, error) {
+	return loadConfig(source{fsys}, name)
+}
+
+func loadConfig(src source, path string) (*Simulator, error) {
+	env, err := loadEnvironment(src, path, nil)
 	if err != nil {
-		return nil, fmt.Errorf("błąd odczytu pliku: %v", err)
+		return nil, err
 	}
 
+	sim := NewSimulator(env)
+	if _, ok := physics.Integrators[sim.Integrator]; !ok {
+		return nil, fmt.Errorf("%s: nieznana metoda całkowania %q", path, env.Integrator)
+	}
+	sim.Boundary, err = env.Boundary.toPhysics()
+	if err != nil {
+		return nil, fmt.Errorf("%s: boundary: %v", path, err)
+	}
+	for i := range sim.Bodies {
+		sim.Boundary.Confine(&sim.Bodies[i])
+	}
+	return sim, nil
+}
+
+// ReadConfig wczytuje konfigurację sceny bez tworzenia symulatora (i bez dołączonych scen).
+// Plik jest najpierw sprawdzany przez Validate; błędy są zwracane jako *ValidationError.
+func ReadConfig(path string) (EnvironmentConfig, error) {
+	return readConfig(source{}, path)
+}
+
+// ReadConfigFS działa jak ReadConfig dla pliku sceny w fsys
+func ReadConfigFS(fsys fs.FS, name string) (EnvironmentConfig, error) {
+	return readConfig(source{fsys}, name)
+}
+
+func readConfig(src source, path string) (EnvironmentConfig, error) {
 	var env EnvironmentConfig
+	data, err := src.read(path)
+	if err != nil {
+		return env, fmt.Errorf("błąd odczytu pliku: %v", err)
+	}
+	if issues := Validate(data); HasErrors(issues) {
+		verr := &ValidationError{}
+		for _, is := range issues {
+			if is.Severity == SeverityError {
+				verr.Issues = append(verr.Issues, is)
+			}
+		}
+		return env, verr
+	}
 	if err := json.Unmarshal(data, &env); err != nil {
-		return nil, fmt.Errorf("błąd parsowania JSON: %v", err)
+		return env, fmt.Errorf("błąd parsowania JSON: %v", err)
 	}
+	return env, nil
+}
 
-	if env.AutoOrbit {
-		SetOrbitalVelocities(env.Bodies)
+// --- Zapis pliku konfiguracyjnego ---
+func SaveConfig(path string, env EnvironmentConfig) error {
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return fmt.Errorf("błąd serializacji JSON: %v", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("błąd zapisu pliku: %v", err)
 	}
+	return nil
+}
 
-	sim := NewSimulator(env)
-	return sim, nil
+// Config zwraca konfigurację sceny odpowiadającą bieżącemu stanowi symulatora;
+// zapisana przez SaveConfig i wczytana ponownie daje te same ciała i ustawienia (bez czasu i przyspieszeń)
+func (s *Simulator) Config() EnvironmentConfig {
+	env := EnvironmentConfig{
+		Name:       s.Name,
+		Dt:         s.Dt,
+		Bodies:     make([]BodyConfig, len(s.Bodies)),
+		Integrator: s.Integrator,
+		Escape:     s.Escape,
+		Boundary:   boundaryConfig(s.Boundary),
+		Units:      unitsConfig(s.Units),
+	}
+	if env.Integrator == physics.DefaultIntegrator {
+		env.Integrator = ""
+	}
+	if s.CloseEncounter > 0 {
+		env.Events = &EventsConfig{CloseEncounter: s.CloseEncounter}
+	}
+	if s.Softening != env.EffectiveSoftening() {
+		softening := s.Softening
+		env.Softening = &softening
+	}
+	for i, b := range s.Bodies {
+		env.Bodies[i] = BodyConfigOf(b)
+	}
+	return env
 }
 
 // --- Parser koloru HEX ---
 func parseColor(hex string) color.RGBA {
+	if c, err := ParseColor(hex); err == nil {
+		return c
+	}
+	return color.RGBA{200, 200, 255, 255}
+}
+
+// ParseColor odczytuje kolor HEX (#rrggbb); w odróżnieniu od plików scen błędny zapis jest błędem
+func ParseColor(hex string) (color.RGBA, error) {
 	var r, g, b uint8
 	if len(hex) == 7 && hex[0] == '#' {
 		n, err := fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b)
 		if err == nil && n == 3 {
-			return color.RGBA{r, g, b, 255}
+			return color.RGBA{r, g, b, 255}, nil
 		}
 	}
-	return color.RGBA{200, 200, 255, 255}
+	return color.RGBA{}, fmt.Errorf("niepoprawny kolor %q (oczekiwano #rrggbb)", hex)
+}
+
+// FormatColor zamienia kolor na zapis HEX (#rrggbb) zgodny z parseColor
+func FormatColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
 }
@@ -0,0 +1,51 @@
+package simulation
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestConfigRoundTrip - scena zapisana z Simulator.Config i wczytana ponownie ma te same ciała i ustawienia
+func TestConfigRoundTrip(t *testing.T) {
+	softening := 0.5
+	sim := NewSimulator(EnvironmentConfig{
+		Name:       "test",
+		Dt:         0.01,
+		Integrator: "leapfrog",
+		Units:      &UnitsConfig{Length: "AU", Mass: "Msun", Time: "yr"},
+		Softening:  &softening,
+		Boundary:   &BoundaryConfig{Type: "periodic", Min: [2]float64{-10, -10}, Max: [2]float64{10, 10}},
+		Events:     &EventsConfig{CloseEncounter: 0.1},
+		Bodies: []BodyConfig{
+			{Name: "sun", Mass: 1, Radius: 0.05, Color: "#ffff00", Locked: true, Tags: []string{"star"}},
+			{Name: "planet", Mass: 3e-6, Pos: [2]float64{1, 0}, Vel: [2]float64{0, 6.28}, Radius: 0.01, Color: "#0000ff"},
+			{Mass: 1e-9, Pos: [2]float64{-2, 0}, Radius: 0.01, Color: "#808080", Anti: true},
+		},
+	})
+	sim.Advance(10)
+
+	path := filepath.Join(t.TempDir(), "scene.json")
+	if err := SaveConfig(path, sim.Config()); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Dt != sim.Dt || loaded.Integrator != sim.Integrator || loaded.Units != sim.Units ||
+		loaded.G != sim.G || loaded.Softening != sim.Softening || loaded.CloseEncounter != sim.CloseEncounter ||
+		!reflect.DeepEqual(loaded.Boundary, sim.Boundary) {
+		t.Fatalf("ustawienia po wczytaniu różnią się:\n%+v\n%+v", loaded, sim)
+	}
+	if len(loaded.Bodies) != len(sim.Bodies) {
+		t.Fatalf("%d ciał, oczekiwano %d", len(loaded.Bodies), len(sim.Bodies))
+	}
+	for i := range sim.Bodies {
+		want := sim.Bodies[i]
+		want.Acc = loaded.Bodies[i].Acc // przyspieszenia nie są zapisywane w scenie
+		if !reflect.DeepEqual(loaded.Bodies[i], want) {
+			t.Errorf("ciało %d:\n%+v\noczekiwano\n%+v", i, loaded.Bodies[i], want)
+		}
+	}
+}
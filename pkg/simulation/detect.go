@@ -0,0 +1,168 @@
+package simulation
+
+import "math"
+
+// EventsConfig - ustawienia detektorów zdarzeń
+type EventsConfig struct {
+	CloseEncounter float64 `json:"close_encounter,omitempty"` // próg odległości bliskiego przejścia (0 = wyłączone)
+}
+
+// detectorState - stan detektorów między krokami. Jest indeksowany identyfikatorami ciał,
+// więc usunięcie lub wstawienie ciała nie przesuwa stanu pozostałych.
+type detectorState struct {
+	contact map[[2]string]bool
+	close   map[[2]string]bool
+	apsis   map[string]apsisState
+	nan     map[string]bool
+}
+
+// apsisState - ostatnie ciało centralne i znak prędkości radialnej względem niego
+type apsisState struct {
+	primary string
+	radial  float64
+}
+
+func (d *detectorState) init() {
+	if d.apsis != nil {
+		return
+	}
+	d.contact = make(map[[2]string]bool)
+	d.close = make(map[[2]string]bool)
+	d.apsis = make(map[string]apsisState)
+	d.nan = make(map[string]bool)
+}
+
+// forget usuwa stan ciała id (wywoływane po usunięciu ciała z symulacji)
+func (d *detectorState) forget(id string) {
+	for key := range d.contact {
+		if key[0] == id || key[1] == id {
+			delete(d.contact, key)
+		}
+	}
+	for key := range d.close {
+		if key[0] == id || key[1] == id {
+			delete(d.close, key)
+		}
+	}
+	for body, st := range d.apsis {
+		if body == id || st.primary == id {
+			delete(d.apsis, body)
+		}
+	}
+	delete(d.nan, id)
+}
+
+// pairKey - klucz pary ciał niezależny od ich kolejności w Bodies
+func pairKey(a, b string) [2]string {
+	if b < a {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// detectEvents uruchamia detektory, dla których istnieją subskrybenci
+func (s *Simulator) detectEvents() {
+	s.detect.init()
+	if s.Events.Wants(EventNaN) {
+		s.detectNaN()
+	}
+	wantCollision := s.Events.Wants(EventCollision)
+	wantClose := s.CloseEncounter > 0 && s.Events.Wants(EventCloseEncounter)
+	if wantCollision || wantClose {
+		s.detectPairs(wantCollision, wantClose)
+	}
+	if s.Events.Wants(EventPeriapsis) || s.Events.Wants(EventApoapsis) {
+		s.detectApsides()
+	}
+}
+
+func (s *Simulator) detectNaN() {
+	for _, b := range s.Bodies {
+		if s.detect.nan[b.ID] {
+			continue
+		}
+		if !finite(b.Pos.X) || !finite(b.Pos.Y) || !finite(b.Vel.X) || !finite(b.Vel.Y) {
+			s.detect.nan[b.ID] = true
+			s.Events.Publish(NaNEvent{Time: s.Time, Step: s.Step, Body: b.ID})
+		}
+	}
+}
+
+// detectPairs zgłasza wejście pary ciał w kontakt lub w strefę bliskiego przejścia (raz na wejście)
+func (s *Simulator) detectPairs(wantCollision, wantClose bool) {
+	d := &s.detect
+	for i := range s.Bodies {
+		for j := i + 1; j < len(s.Bodies); j++ {
+			a, b := s.Bodies[i], s.Bodies[j]
+			dist := s.Boundary.Separation(a.Pos, b.Pos).Len()
+			key := pairKey(a.ID, b.ID)
+			if wantCollision {
+				touching := dist < a.Radius+b.Radius
+				if touching && !d.contact[key] {
+					s.Events.Publish(CollisionEvent{Time: s.Time, Step: s.Step, A: a.ID, B: b.ID, Distance: dist})
+				}
+				setPair(d.contact, key, touching)
+			}
+			if wantClose {
+				near := dist < s.CloseEncounter
+				if near && !d.close[key] {
+					s.Events.Publish(CloseEncounterEvent{Time: s.Time, Step: s.Step, A: a.ID, B: b.ID, Distance: dist})
+				}
+				setPair(d.close, key, near)
+			}
+		}
+	}
+}
+
+// detectApsides śledzi znak prędkości radialnej każdego ciała względem ciała, którego
+// przyciąganie jest dla niego największe; zmiana z - na + to perycentrum, z + na - apocentrum
+func (s *Simulator) detectApsides() {
+	d := &s.detect
+	for i, b := range s.Bodies {
+		if b.Locked {
+			continue
+		}
+		primary, best := -1, 0.0
+		for j, o := range s.Bodies {
+			if j == i || o.Anti {
+				continue
+			}
+			r := s.Boundary.Separation(b.Pos, o.Pos).Len()
+			if pull := o.Mass / (r*r + s.Softening*s.Softening); pull > best {
+				primary, best = j, pull
+			}
+		}
+		if primary < 0 {
+			delete(d.apsis, b.ID)
+			continue
+		}
+		p := s.Bodies[primary]
+		rel := s.Boundary.Separation(p.Pos, b.Pos)
+		vrel := b.Vel.Sub(p.Vel)
+		radial := rel.X*vrel.X + rel.Y*vrel.Y
+		prev, ok := d.apsis[b.ID]
+		if ok && prev.primary == p.ID && prev.radial != 0 && radial != 0 && (prev.radial < 0) != (radial < 0) {
+			s.Events.Publish(ApsisEvent{
+				Time:      s.Time,
+				Step:      s.Step,
+				Body:      b.ID,
+				Primary:   p.ID,
+				Distance:  rel.Len(),
+				Periapsis: radial > 0,
+			})
+		}
+		d.apsis[b.ID] = apsisState{primary: p.ID, radial: radial}
+	}
+}
+
+func setPair(m map[[2]string]bool, key [2]string, v bool) {
+	if v {
+		m[key] = true
+	} else {
+		delete(m, key)
+	}
+}
+
+func finite(x float64) bool {
+	return !math.IsNaN(x) && !math.IsInf(x, 0)
+}
@@ -0,0 +1,46 @@
+package simulation
+
+import "testing"
+
+// TestDetectorStateByID - usunięcie i ponowne wstawienie ciała przed parą stykających się ciał
+// nie powtarza zdarzenia kolizji (stan detektorów jest indeksowany identyfikatorami)
+func TestDetectorStateByID(t *testing.T) {
+	sim := NewSimulator(EnvironmentConfig{
+		Name: "test",
+		Dt:   0.01,
+		Bodies: []BodyConfig{
+			{Name: "x", Mass: 1, Pos: [2]float64{-100, 0}, Radius: 1, Locked: true},
+			{Name: "a", Mass: 1, Pos: [2]float64{0, 0}, Radius: 2, Locked: true},
+			{Name: "b", Mass: 1, Pos: [2]float64{3, 0}, Radius: 2, Locked: true},
+			{Name: "c", Mass: 1, Pos: [2]float64{100, 0}, Radius: 1, Locked: true},
+		},
+	})
+	var got []CollisionEvent
+	sim.Events.Subscribe(func(e Event) { got = append(got, e.(CollisionEvent)) }, EventCollision)
+
+	sim.Update()
+	if len(got) != 1 || pairKey(got[0].A, got[0].B) != pairKey("a", "b") {
+		t.Fatalf("zdarzenia po pierwszym kroku: %v, oczekiwano kolizji a i b", got)
+	}
+	cmd := &RemoveBodyCmd{ID: "x"}
+	if err := cmd.Apply(sim); err != nil {
+		t.Fatal(err)
+	}
+	sim.Update()
+	if err := cmd.Undo(sim); err != nil {
+		t.Fatal(err)
+	}
+	sim.Update()
+	if len(got) != 1 {
+		t.Fatalf("kolizja stykającej się pary zgłoszona ponownie po zmianie zbioru ciał: %v", got)
+	}
+
+	// po rozdzieleniu i ponownym zetknięciu zdarzenie jest zgłaszane znowu
+	sim.Body("b").Pos.X = 10
+	sim.Update()
+	sim.Body("b").Pos.X = 3
+	sim.Update()
+	if len(got) != 2 {
+		t.Fatalf("%d zdarzeń kolizji, oczekiwano 2", len(got))
+	}
+}
@@ -0,0 +1,88 @@
+package simulation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+
+	"gravity-sim/pkg/physics"
+)
+
+// Diagnostics - wielkości zachowane i inne miary stanu układu
+type Diagnostics struct {
+	Bodies          int
+	Kinetic         float64
+	Potential       float64
+	Energy          float64 // Kinetic + Potential
+	Momentum        physics.Vec2
+	AngularMomentum float64 // względem początku układu współrzędnych
+	CenterOfMass    physics.Vec2
+}
+
+// Diagnostics liczy energię, pęd i moment pędu układu. Energia potencjalna jest liczona
+// z G i softeningiem sceny; w pudle okresowym tylko dla najbliższych obrazów.
+func (s *Simulator) Diagnostics() Diagnostics {
+	d := Diagnostics{Bodies: len(s.Bodies)}
+	prm := s.Params()
+	var m float64
+	for i, b := range s.Bodies {
+		d.Kinetic += 0.5 * b.Mass * (b.Vel.X*b.Vel.X + b.Vel.Y*b.Vel.Y)
+		d.Momentum = d.Momentum.Add(b.Vel.Mul(b.Mass))
+		d.AngularMomentum += b.Mass * (b.Pos.X*b.Vel.Y - b.Pos.Y*b.Vel.X)
+		d.CenterOfMass = d.CenterOfMass.Add(b.Pos.Mul(b.Mass))
+		m += b.Mass
+		for j := range s.Bodies {
+			if j != i {
+				// każda para liczona dwa razy, stąd połowa
+				d.Potential += 0.5 * prm.PotentialEnergy(b, s.Bodies[j])
+			}
+		}
+	}
+	if m != 0 {
+		d.CenterOfMass = d.CenterOfMass.Mul(1 / m)
+	}
+	d.Energy = d.Kinetic + d.Potential
+	return d
+}
+
+// DiagnosticsLog - obserwator zapisujący diagnostykę co Every kroków do W jako CSV:
+// krok, czas, liczba ciał, energie, pęd, moment pędu i względny dryf energii od dołączenia
+type DiagnosticsLog struct {
+	W     io.Writer
+	Every int
+
+	Last Diagnostics // ostatnio zapisane wartości
+	e0   float64
+	bw   *bufio.Writer
+}
+
+func (l *DiagnosticsLog) OnStart(s *Simulator) error {
+	l.bw = bufio.NewWriter(l.W)
+	l.e0 = s.Diagnostics().Energy
+	fmt.Fprintln(l.bw, "step,time,bodies,kinetic,potential,energy,px,py,angular_momentum,energy_drift")
+	return l.write(s)
+}
+
+func (l *DiagnosticsLog) OnStep(s *Simulator, _ float64) error {
+	if l.Every > 1 && s.Step%int64(l.Every) != 0 {
+		return nil
+	}
+	return l.write(s)
+}
+
+func (l *DiagnosticsLog) OnStop(*Simulator) error {
+	return l.bw.Flush()
+}
+
+func (l *DiagnosticsLog) write(s *Simulator) error {
+	d := s.Diagnostics()
+	drift := 0.0
+	if l.e0 != 0 {
+		drift = (d.Energy - l.e0) / math.Abs(l.e0)
+	}
+	l.Last = d
+	_, err := fmt.Fprintf(l.bw, "%d,%g,%d,%g,%g,%g,%g,%g,%g,%g\n", s.Step, s.Time, d.Bodies,
+		d.Kinetic, d.Potential, d.Energy, d.Momentum.X, d.Momentum.Y, d.AngularMomentum, drift)
+	return err
+}
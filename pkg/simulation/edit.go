@@ -0,0 +1,408 @@
+package simulation
+
+import (
+	"fmt"
+	"image/color"
+
+	"gravity-sim/pkg/physics"
+)
+
+// Command - odwracalna edycja sceny. Te same obiekty są używane przez GUI (Ctrl+Z / Ctrl+Y)
+// i mogą być stosowane programowo: cmd.Apply(sim), cmd.Undo(sim).
+type Command interface {
+	Apply(s *Simulator) error
+	Undo(s *Simulator) error
+	String() string
+}
+
+// body zwraca wskaźnik na ciało o identyfikatorze id lub błąd, gdy takiego ciała nie ma
+func (s *Simulator) body(id string) (*physics.Body, error) {
+	b := s.Body(id)
+	if b == nil {
+		return nil, fmt.Errorf("brak ciała %q", id)
+	}
+	return b, nil
+}
+
+// AddBodyCmd dodaje ciało na końcu listy; ciało bez ID dostaje identyfikator przy pierwszym
+// wykonaniu i zachowuje go przy ponowieniu
+type AddBodyCmd struct {
+	Body physics.Body
+}
+
+func (c *AddBodyCmd) Apply(s *Simulator) error {
+	id, err := s.AddBody(c.Body)
+	if err != nil {
+		return err
+	}
+	c.Body.ID = id
+	return nil
+}
+
+func (c *AddBodyCmd) Undo(s *Simulator) error {
+	i := s.Index(c.Body.ID)
+	if i < 0 {
+		return fmt.Errorf("brak ciała %q", c.Body.ID)
+	}
+	s.RemoveBody(i)
+	return nil
+}
+
+func (c *AddBodyCmd) String() string { return fmt.Sprintf("dodanie ciała %s", c.Body.ID) }
+
+// RemoveBodyCmd usuwa ciało ID; cofnięcie wstawia je z powrotem w to samo miejsce
+type RemoveBodyCmd struct {
+	ID      string
+	index   int
+	removed physics.Body
+}
+
+func (c *RemoveBodyCmd) Apply(s *Simulator) error {
+	c.index = s.Index(c.ID)
+	if c.index < 0 {
+		return fmt.Errorf("brak ciała %q", c.ID)
+	}
+	c.removed = s.Bodies[c.index]
+	s.RemoveBody(c.index)
+	return nil
+}
+
+func (c *RemoveBodyCmd) Undo(s *Simulator) error {
+	if s.Index(c.ID) >= 0 {
+		return fmt.Errorf("identyfikator %q jest już zajęty", c.ID)
+	}
+	i := min(c.index, len(s.Bodies))
+	s.Bodies = append(s.Bodies[:i], append([]physics.Body{c.removed}, s.Bodies[i:]...)...)
+	return nil
+}
+
+func (c *RemoveBodyCmd) String() string { return fmt.Sprintf("usunięcie ciała %s", c.ID) }
+
+// SetMassCmd ustawia masę ciała
+type SetMassCmd struct {
+	ID   string
+	Mass float64
+	old  float64
+}
+
+func (c *SetMassCmd) Apply(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	c.old, b.Mass = b.Mass, c.Mass
+	return nil
+}
+
+func (c *SetMassCmd) Undo(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	b.Mass = c.old
+	return nil
+}
+
+func (c *SetMassCmd) String() string { return fmt.Sprintf("masa ciała %s = %.3g", c.ID, c.Mass) }
+
+// SetRadiusCmd ustawia promień ciała
+type SetRadiusCmd struct {
+	ID     string
+	Radius float64
+	old    float64
+}
+
+func (c *SetRadiusCmd) Apply(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	c.old, b.Radius = b.Radius, c.Radius
+	return nil
+}
+
+func (c *SetRadiusCmd) Undo(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	b.Radius = c.old
+	return nil
+}
+
+func (c *SetRadiusCmd) String() string {
+	return fmt.Sprintf("promień ciała %s = %.3g", c.ID, c.Radius)
+}
+
+// SetLockedCmd ustawia flagę Locked
+type SetLockedCmd struct {
+	ID     string
+	Locked bool
+	old    bool
+}
+
+func (c *SetLockedCmd) Apply(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	c.old, b.Locked = b.Locked, c.Locked
+	return nil
+}
+
+func (c *SetLockedCmd) Undo(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	b.Locked = c.old
+	return nil
+}
+
+func (c *SetLockedCmd) String() string {
+	return fmt.Sprintf("ciało %s: locked = %v", c.ID, c.Locked)
+}
+
+// SetAntiCmd ustawia flagę Anti
+type SetAntiCmd struct {
+	ID   string
+	Anti bool
+	old  bool
+}
+
+func (c *SetAntiCmd) Apply(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	c.old, b.Anti = b.Anti, c.Anti
+	return nil
+}
+
+func (c *SetAntiCmd) Undo(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	b.Anti = c.old
+	return nil
+}
+
+func (c *SetAntiCmd) String() string { return fmt.Sprintf("ciało %s: anti = %v", c.ID, c.Anti) }
+
+// SetColorCmd ustawia kolor ciała
+type SetColorCmd struct {
+	ID    string
+	Color color.RGBA
+	old   color.RGBA
+}
+
+func (c *SetColorCmd) Apply(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	c.old, b.ColorC = b.ColorC, c.Color
+	return nil
+}
+
+func (c *SetColorCmd) Undo(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	b.ColorC = c.old
+	return nil
+}
+
+func (c *SetColorCmd) String() string {
+	return fmt.Sprintf("ciało %s: kolor %s", c.ID, FormatColor(c.Color))
+}
+
+// EditBodyCmd zmienia podane pola ciała (nil = bez zmian), np. przy PATCH w API HTTP;
+// cofnięcie przywraca tylko te pola, więc nie cofa ruchu ciała od czasu edycji
+type EditBodyCmd struct {
+	ID     string
+	Name   *string
+	Mass   *float64
+	Pos    *physics.Vec2
+	Vel    *physics.Vec2
+	Radius *float64
+	Color  *color.RGBA
+	Locked *bool
+	Anti   *bool
+	Tags   []string // nil = bez zmian
+	old    physics.Body
+}
+
+func (c *EditBodyCmd) Apply(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	c.old = *b
+	c.copy(b, c.value())
+	return nil
+}
+
+func (c *EditBodyCmd) Undo(s *Simulator) error {
+	b, err := s.body(c.ID)
+	if err != nil {
+		return err
+	}
+	c.copy(b, c.old)
+	return nil
+}
+
+func (c *EditBodyCmd) String() string { return fmt.Sprintf("edycja ciała %s", c.ID) }
+
+// value zwraca ciało z nowymi wartościami pól (pola bez zmian są zerowe)
+func (c *EditBodyCmd) value() physics.Body {
+	var v physics.Body
+	if c.Name != nil {
+		v.Name = *c.Name
+	}
+	if c.Mass != nil {
+		v.Mass = *c.Mass
+	}
+	if c.Pos != nil {
+		v.Pos = *c.Pos
+	}
+	if c.Vel != nil {
+		v.Vel = *c.Vel
+	}
+	if c.Radius != nil {
+		v.Radius = *c.Radius
+	}
+	if c.Color != nil {
+		v.ColorC = *c.Color
+	}
+	if c.Locked != nil {
+		v.Locked = *c.Locked
+	}
+	if c.Anti != nil {
+		v.Anti = *c.Anti
+	}
+	v.Tags = c.Tags
+	return v
+}
+
+// copy przepisuje z src do b pola, które edycja zmienia
+func (c *EditBodyCmd) copy(b *physics.Body, src physics.Body) {
+	if c.Name != nil {
+		b.Name = src.Name
+	}
+	if c.Mass != nil {
+		b.Mass = src.Mass
+	}
+	if c.Pos != nil {
+		b.Pos = src.Pos
+	}
+	if c.Vel != nil {
+		b.Vel = src.Vel
+	}
+	if c.Radius != nil {
+		b.Radius = src.Radius
+	}
+	if c.Color != nil {
+		b.ColorC = src.ColorC
+	}
+	if c.Locked != nil {
+		b.Locked = src.Locked
+	}
+	if c.Anti != nil {
+		b.Anti = src.Anti
+	}
+	if c.Tags != nil {
+		b.Tags = src.Tags
+	}
+}
+
+// BatchCmd - kilka edycji wykonywanych i cofanych jako jedna
+type BatchCmd []Command
+
+func (c BatchCmd) Apply(s *Simulator) error {
+	for i, cmd := range c {
+		if err := cmd.Apply(s); err != nil {
+			// wycofaj już wykonane części
+			for j := i - 1; j >= 0; j-- {
+				c[j].Undo(s)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (c BatchCmd) Undo(s *Simulator) error {
+	for i := len(c) - 1; i >= 0; i-- {
+		if err := c[i].Undo(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c BatchCmd) String() string {
+	if len(c) == 0 {
+		return "pusta edycja"
+	}
+	return c[0].String()
+}
+
+// --- Historia edycji ---
+
+// EditHistory - stos cofania i ponawiania edycji
+type EditHistory struct {
+	Limit int // maksymalna liczba pamiętanych edycji (0 = bez limitu)
+
+	undo []Command
+	redo []Command
+}
+
+// Do wykonuje edycję i zapamiętuje ją do cofnięcia; czyści stos ponawiania
+func (h *EditHistory) Do(s *Simulator, c Command) error {
+	if err := c.Apply(s); err != nil {
+		return err
+	}
+	h.undo = append(h.undo, c)
+	if h.Limit > 0 && len(h.undo) > h.Limit {
+		h.undo = h.undo[len(h.undo)-h.Limit:]
+	}
+	h.redo = nil
+	return nil
+}
+
+// Undo cofa ostatnią edycję; zwraca cofniętą edycję lub nil, gdy nie ma czego cofać
+func (h *EditHistory) Undo(s *Simulator) (Command, error) {
+	if len(h.undo) == 0 {
+		return nil, nil
+	}
+	c := h.undo[len(h.undo)-1]
+	if err := c.Undo(s); err != nil {
+		return nil, err
+	}
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, c)
+	return c, nil
+}
+
+// Redo ponawia ostatnio cofniętą edycję; zwraca ją lub nil, gdy nie ma czego ponawiać
+func (h *EditHistory) Redo(s *Simulator) (Command, error) {
+	if len(h.redo) == 0 {
+		return nil, nil
+	}
+	c := h.redo[len(h.redo)-1]
+	if err := c.Apply(s); err != nil {
+		return nil, err
+	}
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, c)
+	return c, nil
+}
+
+// Clear zapomina wszystkie edycje (np. po wczytaniu innej sceny)
+func (h *EditHistory) Clear() {
+	h.undo = nil
+	h.redo = nil
+}
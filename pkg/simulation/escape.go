@@ -0,0 +1,123 @@
+package simulation
+
+import (
+	"gravity-sim/pkg/physics"
+)
+
+// EscapeConfig - wykrywanie ciał, które uciekły z układu
+type EscapeConfig struct {
+	Radius     float64 `json:"radius,omitempty"`      // minimalna odległość od środka masy reszty układu (0 = bez ograniczenia)
+	Remove     bool    `json:"remove,omitempty"`      // usuwaj ciała, które uciekły
+	CheckEvery int     `json:"check_every,omitempty"` // co ile kroków sprawdzać (domyślnie 10)
+}
+
+// BoundState - klasyfikacja ciała względem reszty układu
+type BoundState int
+
+const (
+	Bound   BoundState = iota // energia ujemna
+	Unbound                   // energia nieujemna, ale ciało jeszcze nie uciekło
+	Escaped                   // niezwiązane, oddala się i jest poza promieniem Radius
+)
+
+func (b BoundState) String() string {
+	switch b {
+	case Bound:
+		return "bound"
+	case Unbound:
+		return "unbound"
+	default:
+		return "escaped"
+	}
+}
+
+// EscapeEvent - zdarzenie ucieczki ciała; publikowane przed ewentualnym usunięciem ciała z Bodies
+type EscapeEvent struct {
+	Index    int          // indeks ciała w Bodies w chwili zdarzenia
+	Body     physics.Body // stan ciała; Body.ID identyfikuje je także po usunięciu
+	Time     float64
+	Step     int64
+	Energy   float64 // energia ciała względem reszty układu
+	Distance float64 // odległość od środka masy reszty układu
+	Removed  bool    // czy ciało zostanie usunięte z symulacji
+}
+
+// Classify określa, czy ciało i jest związane z resztą układu.
+// Energia liczona jest w układzie środka masy pozostałych ciał: E = ½ m |v - v_cm|² + Σ U_ij.
+func (s *Simulator) Classify(i int) (state BoundState, energy, dist float64) {
+	m, p, q := s.totals()
+	return s.classify(i, m, p, q)
+}
+
+// totals zwraca masę, pęd i moment masy (Σ m·r) całego układu
+func (s *Simulator) totals() (m float64, p, q physics.Vec2) {
+	for _, b := range s.Bodies {
+		m += b.Mass
+		p = p.Add(b.Vel.Mul(b.Mass))
+		q = q.Add(b.Pos.Mul(b.Mass))
+	}
+	return m, p, q
+}
+
+func (s *Simulator) classify(i int, m float64, p, q physics.Vec2) (BoundState, float64, float64) {
+	b := s.Bodies[i]
+	rest := m - b.Mass
+	if rest <= 0 {
+		return Bound, 0, 0
+	}
+	com := q.Sub(b.Pos.Mul(b.Mass)).Mul(1 / rest)
+	vcom := p.Sub(b.Vel.Mul(b.Mass)).Mul(1 / rest)
+	rel := b.Pos.Sub(com)
+	vrel := b.Vel.Sub(vcom)
+
+	energy := 0.5 * b.Mass * vrel.Len() * vrel.Len()
+	prm := s.Params()
+	for j := range s.Bodies {
+		if j != i {
+			energy += prm.PotentialEnergy(b, s.Bodies[j])
+		}
+	}
+	dist := rel.Len()
+	switch {
+	case energy < 0:
+		return Bound, energy, dist
+	case rel.X*vrel.X+rel.Y*vrel.Y > 0 && (s.Escape == nil || dist > s.Escape.Radius):
+		return Escaped, energy, dist
+	default:
+		return Unbound, energy, dist
+	}
+}
+
+// detectEscapes szuka ciał, które uciekły, publikuje EscapeEvent i opcjonalnie usuwa ciała.
+// Ciała są przetwarzane od końca, więc indeksy w zdarzeniach pozostają poprawne
+// także wtedy, gdy odbiorca usuwa odpowiadające im elementy z własnych tablic.
+func (s *Simulator) detectEscapes() {
+	m, p, q := s.totals()
+	var escaped []EscapeEvent
+	for i := range s.Bodies {
+		if s.Bodies[i].Locked {
+			continue
+		}
+		state, energy, dist := s.classify(i, m, p, q)
+		if state == Escaped {
+			escaped = append(escaped, EscapeEvent{
+				Index:    i,
+				Body:     s.Bodies[i],
+				Time:     s.Time,
+				Step:     s.Step,
+				Energy:   energy,
+				Distance: dist,
+				Removed:  s.Escape.Remove,
+			})
+		}
+	}
+	for k := len(escaped) - 1; k >= 0; k-- {
+		ev := escaped[k]
+		if !s.replaying {
+			s.Events.Publish(ev)
+		}
+		if ev.Removed {
+			s.RemoveBody(ev.Index)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package simulation
+
+import (
+	"fmt"
+	"sync"
+)
+
+// EventKind - rodzaj zdarzenia symulacji
+type EventKind int
+
+const (
+	EventCollision      EventKind = iota // zetknięcie dwóch ciał (odległość < suma promieni)
+	EventEscape                          // ucieczka ciała z układu
+	EventCloseEncounter                  // zbliżenie poniżej progu CloseEncounter
+	EventPeriapsis                       // przejście przez perycentrum
+	EventApoapsis                        // przejście przez apocentrum
+	EventNaN                             // NaN lub Inf w stanie ciała
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventCollision:
+		return "collision"
+	case EventEscape:
+		return "escape"
+	case EventCloseEncounter:
+		return "close-encounter"
+	case EventPeriapsis:
+		return "periapsis"
+	case EventApoapsis:
+		return "apoapsis"
+	case EventNaN:
+		return "nan"
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// Event - wspólny interfejs zdarzeń publikowanych przez Simulator.Events
+type Event interface {
+	Kind() EventKind
+	When() float64 // czas symulacji
+	String() string
+}
+
+// CollisionEvent - dwa ciała weszły w kontakt
+type CollisionEvent struct {
+	Time     float64
+	Step     int64
+	A, B     string // identyfikatory ciał (Body.ID)
+	Distance float64
+}
+
+func (e CollisionEvent) Kind() EventKind { return EventCollision }
+func (e CollisionEvent) When() float64   { return e.Time }
+func (e CollisionEvent) String() string {
+	return fmt.Sprintf("kolizja ciał %s i %s (d=%.2f)", e.A, e.B, e.Distance)
+}
+
+// CloseEncounterEvent - dwa ciała zbliżyły się na odległość mniejszą niż próg
+type CloseEncounterEvent struct {
+	Time     float64
+	Step     int64
+	A, B     string
+	Distance float64
+}
+
+func (e CloseEncounterEvent) Kind() EventKind { return EventCloseEncounter }
+func (e CloseEncounterEvent) When() float64   { return e.Time }
+func (e CloseEncounterEvent) String() string {
+	return fmt.Sprintf("bliskie przejście ciał %s i %s (d=%.2f)", e.A, e.B, e.Distance)
+}
+
+// ApsisEvent - przejście ciała przez perycentrum lub apocentrum względem ciała dominującego
+type ApsisEvent struct {
+	Time      float64
+	Step      int64
+	Body      string
+	Primary   string // ciało, którego przyciąganie dominuje
+	Distance  float64
+	Periapsis bool // false = apocentrum
+}
+
+func (e ApsisEvent) Kind() EventKind {
+	if e.Periapsis {
+		return EventPeriapsis
+	}
+	return EventApoapsis
+}
+func (e ApsisEvent) When() float64 { return e.Time }
+func (e ApsisEvent) String() string {
+	name := "apocentrum"
+	if e.Periapsis {
+		name = "perycentrum"
+	}
+	return fmt.Sprintf("ciało %s: %s względem %s (r=%.2f)", e.Body, name, e.Primary, e.Distance)
+}
+
+// NaNEvent - stan ciała zawiera NaN lub nieskończoność (zgłaszane raz na ciało)
+type NaNEvent struct {
+	Time float64
+	Step int64
+	Body string
+}
+
+func (e NaNEvent) Kind() EventKind { return EventNaN }
+func (e NaNEvent) When() float64   { return e.Time }
+func (e NaNEvent) String() string {
+	return fmt.Sprintf("ciało %s: NaN/Inf w pozycji lub prędkości", e.Body)
+}
+
+func (e EscapeEvent) Kind() EventKind { return EventEscape }
+func (e EscapeEvent) When() float64   { return e.Time }
+func (e EscapeEvent) String() string {
+	s := fmt.Sprintf("ciało %s uciekło z układu (E=%.3e, r=%.1f)", e.Body.ID, e.Energy, e.Distance)
+	if e.Removed {
+		s += ", usunięte"
+	}
+	return s
+}
+
+// --- Szyna zdarzeń ---
+
+// EventBus rozsyła zdarzenia do subskrybentów. Subskrybenci są wołani synchronicznie,
+// w goroutine, która wykonuje krok symulacji.
+type EventBus struct {
+	mu   sync.Mutex
+	subs []subscription
+	next int
+}
+
+type subscription struct {
+	id    int
+	kinds map[EventKind]bool // nil = wszystkie rodzaje
+	fn    func(Event)
+}
+
+// Subscribe rejestruje odbiorcę zdarzeń podanych rodzajów (bez rodzajów = wszystkie).
+// Zwraca funkcję wyrejestrowującą.
+func (b *EventBus) Subscribe(fn func(Event), kinds ...EventKind) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sub := subscription{id: b.next, fn: fn}
+	b.next++
+	if len(kinds) > 0 {
+		sub.kinds = make(map[EventKind]bool, len(kinds))
+		for _, k := range kinds {
+			sub.kinds[k] = true
+		}
+	}
+	b.subs = append(b.subs, sub)
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.id == sub.id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Wants informuje, czy ktokolwiek słucha zdarzeń danego rodzaju (detektory bez odbiorców nie są uruchamiane)
+func (b *EventBus) Wants(k EventKind) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.subs {
+		if s.kinds == nil || s.kinds[k] {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish przekazuje zdarzenie wszystkim zainteresowanym odbiorcom
+func (b *EventBus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	subs := make([]subscription, 0, len(b.subs))
+	for _, s := range b.subs {
+		if s.kinds == nil || s.kinds[e.Kind()] {
+			subs = append(subs, s)
+		}
+	}
+	b.mu.Unlock()
+	for _, s := range subs {
+		s.fn(e)
+	}
+}
@@ -0,0 +1,130 @@
+package simulation
+
+import (
+	"fmt"
+	"unsafe"
+
+	"gravity-sim/pkg/physics"
+)
+
+// keyframe - zapamiętany pełny stan ciał w danym kroku
+type keyframe struct {
+	step   int64
+	time   float64
+	bodies []physics.Body
+}
+
+func (k keyframe) size() int {
+	return int(unsafe.Sizeof(k)) + len(k.bodies)*int(unsafe.Sizeof(physics.Body{}))
+}
+
+// History - ograniczony bufor przeszłych stanów symulacji do przewijania.
+// Co KeyframeEvery kroków zapamiętywany jest pełny stan; stany pośrednie są odtwarzane
+// przez ponowne przeliczenie od najbliższej wcześniejszej klatki (symulacja jest deterministyczna).
+// Gdy zajęta pamięć przekracza Budget, najstarsze klatki są usuwane.
+type History struct {
+	Budget        int // limit pamięci w bajtach
+	KeyframeEvery int // co ile kroków zapisywać klatkę
+
+	frames []keyframe // posortowane rosnąco po kroku
+	used   int
+}
+
+// NewHistory tworzy bufor historii o danym limicie pamięci i odstępie klatek
+func NewHistory(budget, keyframeEvery int) *History {
+	if keyframeEvery <= 0 {
+		keyframeEvery = 1
+	}
+	return &History{Budget: budget, KeyframeEvery: keyframeEvery}
+}
+
+// Range zwraca najwcześniejszy i najpóźniejszy zapamiętany krok (ok = false, gdy bufor jest pusty)
+func (h *History) Range() (first, last int64, ok bool) {
+	if h == nil || len(h.frames) == 0 {
+		return 0, 0, false
+	}
+	return h.frames[0].step, h.frames[len(h.frames)-1].step, true
+}
+
+// Len zwraca liczbę zapamiętanych klatek
+func (h *History) Len() int {
+	if h == nil {
+		return 0
+	}
+	return len(h.frames)
+}
+
+// record zapisuje klatkę bieżącego stanu. Klatki z tego samego lub późniejszego kroku
+// są odrzucane - wznowienie symulacji z wcześniejszego punktu tworzy nową gałąź historii.
+func (h *History) record(s *Simulator) {
+	for len(h.frames) > 0 && h.frames[len(h.frames)-1].step >= s.Step {
+		h.used -= h.frames[len(h.frames)-1].size()
+		h.frames = h.frames[:len(h.frames)-1]
+	}
+	kf := keyframe{
+		step:   s.Step,
+		time:   s.Time,
+		bodies: append([]physics.Body(nil), s.Bodies...),
+	}
+	h.frames = append(h.frames, kf)
+	h.used += kf.size()
+	for h.used > h.Budget && len(h.frames) > 1 {
+		h.used -= h.frames[0].size()
+		h.frames[0] = keyframe{}
+		h.frames = h.frames[1:]
+	}
+}
+
+// find zwraca indeks ostatniej klatki o kroku <= step (-1, gdy brak)
+func (h *History) find(step int64) int {
+	lo, hi := 0, len(h.frames)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if h.frames[mid].step <= step {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo - 1
+}
+
+// EnableHistory włącza zapamiętywanie historii z limitem pamięci budget (bajty) i klatką co keyframeEvery kroków
+func (s *Simulator) EnableHistory(budget, keyframeEvery int) {
+	s.History = NewHistory(budget, keyframeEvery)
+	s.History.record(s)
+}
+
+// RecordKeyframe zapisuje klatkę bieżącego stanu poza regularnym odstępem (np. po edycji sceny),
+// aby przewijanie nie gubiło zmian wprowadzonych między klatkami
+func (s *Simulator) RecordKeyframe() {
+	if s.History != nil {
+		s.History.record(s)
+	}
+}
+
+// SeekStep przywraca stan z kroku step: wczytuje najbliższą wcześniejszą klatkę i przelicza
+// brakujące kroki bez publikowania zdarzeń. Historia "w przód" zostaje zachowana,
+// dopóki symulacja nie zostanie wznowiona.
+func (s *Simulator) SeekStep(step int64) error {
+	if s.History == nil {
+		return fmt.Errorf("historia symulacji jest wyłączona")
+	}
+	i := s.History.find(step)
+	if i < 0 {
+		first, _, _ := s.History.Range()
+		return fmt.Errorf("krok %d jest starszy niż historia (od kroku %d)", step, first)
+	}
+	kf := s.History.frames[i]
+	s.Bodies = append(s.Bodies[:0:0], kf.bodies...)
+	s.Time = kf.time
+	s.Step = kf.step
+	s.detect = detectorState{} // stan detektorów pochodzi z innej chwili
+
+	s.replaying = true
+	defer func() { s.replaying = false }()
+	for s.Step < step {
+		s.Update()
+	}
+	return nil
+}
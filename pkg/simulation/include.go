@@ -0,0 +1,201 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gravity-sim/pkg/units"
+)
+
+// IncludeConfig - dołączenie innej sceny z przesunięciem, prędkością unoszenia i obrotem
+type IncludeConfig struct {
+	Path     string     `json:"path"`               // ścieżka względem pliku, który dołącza
+	Offset   [2]float64 `json:"offset,omitempty"`   // przesunięcie dołączonej sceny
+	Vel      [2]float64 `json:"vel,omitempty"`      // prędkość dodawana do wszystkich ciał sceny
+	Rotation float64    `json:"rotation,omitempty"` // obrót wokół początku dołączonej sceny, w stopniach
+}
+
+// source - skąd czytane są pliki scen: dysk (fsys == nil) albo fs.FS, np. sceny wbudowane w program
+type source struct {
+	fsys fs.FS
+}
+
+func (src source) read(name string) ([]byte, error) {
+	if src.fsys == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(src.fsys, name)
+}
+
+// include zwraca ścieżkę pliku dołączonego przez plik from (rel jest względna wobec from)
+func (src source) include(from, rel string) string {
+	if src.fsys == nil {
+		if filepath.IsAbs(rel) {
+			return rel
+		}
+		return filepath.Join(filepath.Dir(from), rel)
+	}
+	return path.Join(path.Dir(from), rel)
+}
+
+// key zwraca ścieżkę identyfikującą plik przy wykrywaniu cykli
+func (src source) key(name string) (string, error) {
+	if src.fsys == nil {
+		return filepath.Abs(name)
+	}
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("niepoprawna ścieżka %q", name)
+	}
+	return name, nil
+}
+
+// LoadEnvironment wczytuje scenę wraz ze wszystkimi scenami dołączonymi przez "includes".
+// Zwrócona konfiguracja jest spłaszczona: ma pustą listę Includes, szablony ("templates")
+// są rozwinięte w ciała, a auto_orbit został już zastosowany osobno w obrębie każdego pliku.
+func LoadEnvironment(path string) (EnvironmentConfig, error) {
+	return loadEnvironment(source{}, path, nil)
+}
+
+// LoadEnvironmentFS działa jak LoadEnvironment, ale czyta pliki z fsys (ścieżki w stylu fs.FS,
+// dołączone sceny względem pliku, który je dołącza)
+func LoadEnvironmentFS(fsys fs.FS, name string) (EnvironmentConfig, error) {
+	return loadEnvironment(source{fsys}, name, nil)
+}
+
+func loadEnvironment(src source, path string, stack []string) (EnvironmentConfig, error) {
+	abs, err := src.key(path)
+	if err != nil {
+		return EnvironmentConfig{}, err
+	}
+	for i, p := range stack {
+		if p == abs {
+			cycle := append(append([]string{}, stack[i:]...), abs)
+			return EnvironmentConfig{}, fmt.Errorf("cykliczne dołączanie scen: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	stack = append(stack, abs)
+
+	env, err := readConfig(src, path)
+	if err != nil {
+		return env, fmt.Errorf("%s: %v", path, err)
+	}
+	sys, err := env.Units.toUnits()
+	if err != nil {
+		return env, fmt.Errorf("%s: units: %v", path, err)
+	}
+
+	includes := env.Includes
+	env.Includes = nil
+	own := len(env.Bodies)
+	// plik bez własnego dt przejmuje najmniejszy krok spośród dołączonych scen
+	inheritDt := env.Dt <= 0
+	// plik bez bloku units przejmuje jednostki dołączonych scen (o ile nie dołącza też scen bez jednostek),
+	// ale tylko wtedy, gdy nie ma własnych wartości - te zmieniłyby znaczenie po cichu
+	inheritUnits := env.Units == nil && !env.hasOwnValues()
+	legacy := false
+	for i, inc := range includes {
+		if inc.Path == "" {
+			return env, fmt.Errorf("%s: includes[%d]: brak pola \"path\"", path, i)
+		}
+		sub, err := loadEnvironment(src, src.include(path, inc.Path), stack)
+		if err != nil {
+			return env, fmt.Errorf("%s: includes[%d]: %w", path, i, err)
+		}
+		subSys, _ := sub.Units.toUnits()
+		if env.Units == nil && sub.Units != nil && !legacy {
+			if !inheritUnits {
+				return env, fmt.Errorf("%s: includes[%d]: dołączona scena ma jednostki %s, a ten plik nie ma bloku units - dodaj go, aby określić jednostki własnych wartości", path, i, subSys)
+			}
+			env.Units, sys = sub.Units, subSys
+		}
+		legacy = legacy || sub.Units == nil
+		if subSys != sys {
+			if err := convertBodies(sub.Bodies, subSys, sys); err != nil {
+				return env, fmt.Errorf("%s: includes[%d]: %v", path, i, err)
+			}
+			sub.Dt, _ = subSys.Convert(sub.Dt, units.Time, sys)
+		}
+		for _, b := range sub.Bodies {
+			env.Bodies = append(env.Bodies, inc.transform(b))
+		}
+		if inheritDt && sub.Dt > 0 && (env.Dt <= 0 || sub.Dt < env.Dt) {
+			env.Dt = sub.Dt
+		}
+	}
+	if env.AutoOrbit {
+		// auto_orbit dotyczy tylko ciał z tego pliku (pierwsze ciało jest centralne)
+		SetOrbitalVelocities(env.Bodies[:own], env.G())
+		env.AutoOrbit = false
+	}
+	// szablony na końcu, aby center mógł wskazywać ciało z dołączonej sceny (z ustaloną już prędkością)
+	for i, t := range env.Templates {
+		bodies, err := t.expand(env.Bodies, env.G())
+		if err != nil {
+			return env, fmt.Errorf("%s: templates[%d]: %v", path, i, err)
+		}
+		env.Bodies = append(env.Bodies, bodies...)
+	}
+	env.Templates = nil
+	return env, nil
+}
+
+// SceneFiles zwraca plik sceny i wszystkie pliki, które dołącza (bezpośrednio lub pośrednio),
+// np. do obserwowania zmian. Plik, którego nie da się odczytać albo sparsować, jest zwracany
+// bez scen, które dołącza - w trakcie edycji scena bywa chwilowo niepoprawna.
+func SceneFiles(path string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	var walk func(path string)
+	walk = func(path string) {
+		abs, err := filepath.Abs(path)
+		if err != nil || seen[abs] {
+			return
+		}
+		seen[abs] = true
+		files = append(files, path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		var env struct {
+			Includes []IncludeConfig `json:"includes"`
+		}
+		if json.Unmarshal(data, &env) != nil {
+			return
+		}
+		for _, inc := range env.Includes {
+			if inc.Path != "" {
+				walk(source{}.include(path, inc.Path))
+			}
+		}
+	}
+	walk(path)
+	return files
+}
+
+// hasOwnValues mówi, czy plik ma własne wartości zależne od jednostek (ciała, szablony, dt,
+// softening, warunki brzegowe, ucieczki, progi zdarzeń); przesunięcia dołączonych scen się nie liczą
+func (env EnvironmentConfig) hasOwnValues() bool {
+	return len(env.Bodies) > 0 || len(env.Templates) > 0 || env.Dt > 0 || env.Softening != nil ||
+		env.Boundary != nil || env.Escape != nil || env.Events != nil
+}
+
+// transform obraca, przesuwa i nadaje prędkość unoszenia ciału z dołączonej sceny
+func (inc IncludeConfig) transform(b BodyConfig) BodyConfig {
+	if inc.Rotation != 0 {
+		s, c := math.Sincos(inc.Rotation * math.Pi / 180)
+		b.Pos = [2]float64{c*b.Pos[0] - s*b.Pos[1], s*b.Pos[0] + c*b.Pos[1]}
+		b.Vel = [2]float64{c*b.Vel[0] - s*b.Vel[1], s*b.Vel[0] + c*b.Vel[1]}
+	}
+	b.Pos[0] += inc.Offset[0]
+	b.Pos[1] += inc.Offset[1]
+	b.Vel[0] += inc.Vel[0]
+	b.Vel[1] += inc.Vel[1]
+	return b
+}
@@ -0,0 +1,195 @@
+package simulation
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"gravity-sim/pkg/units"
+)
+
+func sceneFS(files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, data := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	return fsys
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+func TestIncludeCycle(t *testing.T) {
+	fsys := sceneFS(map[string]string{
+		"a.json":     `{"name": "a", "dt": 1, "includes": [{"path": "sub/b.json"}]}`,
+		"sub/b.json": `{"name": "b", "dt": 1, "includes": [{"path": "../a.json"}]}`,
+		"self.json":  `{"name": "self", "dt": 1, "includes": [{"path": "self.json"}]}`,
+	})
+	for _, tc := range []struct{ name, want string }{
+		{"a.json", "a.json -> sub/b.json -> a.json"},
+		{"self.json", "self.json -> self.json"},
+	} {
+		_, err := LoadEnvironmentFS(fsys, tc.name)
+		if err == nil || !strings.Contains(err.Error(), "cykliczne dołączanie scen: "+tc.want) {
+			t.Errorf("%s: błąd %v, oczekiwano cyklu %s", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestIncludeTransform(t *testing.T) {
+	fsys := sceneFS(map[string]string{
+		"main.json": `{"name": "main", "dt": 0.5, "bodies": [{"name": "own", "mass": 1, "pos": [1, 2], "vel": [3, 4], "radius": 1, "color": "#ffffff"}],
+			"includes": [{"path": "pair.json", "offset": [100, -50], "vel": [1, 1], "rotation": 90}]}`,
+		"pair.json": `{"name": "pair", "dt": 0.1, "bodies": [
+			{"name": "p", "mass": 1, "pos": [10, 0], "vel": [0, 2], "radius": 1, "color": "#ffffff"},
+			{"name": "q", "mass": 1, "pos": [0, 5], "vel": [-3, 0], "radius": 1, "color": "#ffffff"}]}`,
+	})
+	env, err := LoadEnvironmentFS(fsys, "main.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Dt != 0.5 || len(env.Includes) != 0 {
+		t.Fatalf("dt %g, includes %v - plik z własnym dt go zachowuje, a includes są spłaszczone", env.Dt, env.Includes)
+	}
+	want := []struct {
+		name     string
+		pos, vel [2]float64
+	}{
+		{"own", [2]float64{1, 2}, [2]float64{3, 4}},
+		// obrót o 90°: (x, y) -> (-y, x), potem przesunięcie i prędkość unoszenia
+		{"p", [2]float64{100, -40}, [2]float64{-1, 1}},
+		{"q", [2]float64{95, -50}, [2]float64{1, -2}},
+	}
+	if len(env.Bodies) != len(want) {
+		t.Fatalf("%d ciał, oczekiwano %d", len(env.Bodies), len(want))
+	}
+	for i, w := range want {
+		b := env.Bodies[i]
+		if b.Name != w.name || !near(b.Pos[0], w.pos[0]) || !near(b.Pos[1], w.pos[1]) ||
+			!near(b.Vel[0], w.vel[0]) || !near(b.Vel[1], w.vel[1]) {
+			t.Errorf("ciało %d: %s pos %v vel %v, oczekiwano %s pos %v vel %v", i, b.Name, b.Pos, b.Vel, w.name, w.pos, w.vel)
+		}
+	}
+}
+
+func TestIncludeUnits(t *testing.T) {
+	const (
+		yrScene = `{"name": "yr", "dt": 0.01, "units": {"length": "AU", "mass": "Msun", "time": "yr"},
+			"bodies": [{"name": "yr", "mass": 2, "pos": [1, 0], "vel": [0, 6.28], "radius": 0.001, "color": "#ffffff"}]}`
+		legacyScene = `{"name": "legacy", "dt": 0.1, "bodies": [{"name": "legacy", "mass": 5, "pos": [7, 0], "radius": 1, "color": "#ffffff"}]}`
+	)
+	yr, err := units.New("AU", "Msun", "yr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day, err := units.New("AU", "Msun", "day")
+	if err != nil {
+		t.Fatal(err)
+	}
+	perDay, _ := yr.Convert(6.28, units.Velocity, day)
+	dtDays, _ := yr.Convert(0.01, units.Time, day)
+
+	for _, tc := range []struct {
+		name  string
+		main  string
+		units units.System
+		g     float64
+		dt    float64
+		vel   map[string]float64 // oczekiwana prędkość Y ciał
+		err   string
+		valid bool // błąd zgłasza też ValidateFS
+	}{
+		{
+			name:  "konwersja do jednostek pliku",
+			main:  `{"name": "m", "units": {"length": "AU", "mass": "Msun", "time": "day"}, "bodies": [{"name": "own", "mass": 1, "vel": [0, 2], "radius": 0.001, "color": "#ffffff"}], "includes": [{"path": "yr.json"}]}`,
+			units: day, g: day.G(), dt: dtDays,
+			vel: map[string]float64{"own": 2, "yr": perDay},
+		},
+		{
+			name:  "plik bez units i bez własnych wartości przejmuje jednostki",
+			main:  `{"name": "m", "includes": [{"path": "yr.json", "offset": [1, 0]}]}`,
+			units: yr, g: yr.G(), dt: 0.01,
+			vel: map[string]float64{"yr": 6.28},
+		},
+		{
+			name: "plik bez units nie przejmuje jednostek, które zmieniłyby jego wartości",
+			main: `{"name": "m", "dt": 0.1, "bodies": [{"name": "own", "mass": 1, "radius": 1, "color": "#ffffff"}], "includes": [{"path": "yr.json"}]}`,
+			err:  "includes[0]: dołączona scena ma jednostki AU / Msun / yr",
+		},
+		{
+			name: "scena bez jednostek w pliku z jednostkami",
+			main: `{"name": "m", "dt": 1, "units": {"length": "AU", "mass": "Msun", "time": "day"}, "includes": [{"path": "legacy.json"}]}`,
+			err:  "includes[0]: nie można przeliczyć jednostek",
+		},
+		{
+			name: "scena z jednostkami w pliku bez jednostek",
+			main: `{"name": "m", "includes": [{"path": "legacy.json"}, {"path": "yr.json"}]}`,
+			err:  "includes[1]: nie można przeliczyć jednostek",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fsys := sceneFS(map[string]string{"main.json": tc.main, "yr.json": yrScene, "legacy.json": legacyScene})
+			env, err := LoadEnvironmentFS(fsys, "main.json")
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("błąd %v, oczekiwano %q", err, tc.err)
+				}
+				if tc.valid {
+					if issues, _ := ValidateFS(fsys, "main.json"); !HasErrors(issues) {
+						t.Fatalf("ValidateFS nie zgłasza błędu: %v", issues)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			sys, err := env.UnitSystem()
+			if err != nil || sys != tc.units || !near(env.G(), tc.g) || !near(env.Dt, tc.dt) {
+				t.Fatalf("jednostki %v (%v), G %g, dt %g; oczekiwano %v, %g, %g", sys, err, env.G(), env.Dt, tc.units, tc.g, tc.dt)
+			}
+			if len(env.Bodies) != len(tc.vel) {
+				t.Fatalf("%d ciał, oczekiwano %d", len(env.Bodies), len(tc.vel))
+			}
+			for _, b := range env.Bodies {
+				if !near(b.Vel[1], tc.vel[b.Name]) {
+					t.Errorf("%s: prędkość %g, oczekiwano %g", b.Name, b.Vel[1], tc.vel[b.Name])
+				}
+			}
+		})
+	}
+}
+
+// TestIncludeIgnoredOptions - ustawienia dołączonej sceny, których spłaszczona scena nie przejmuje,
+// są zgłaszane przez ValidateFS jako ostrzeżenia
+func TestIncludeIgnoredOptions(t *testing.T) {
+	fsys := sceneFS(map[string]string{
+		"main.json": `{"name": "m", "dt": 1, "integrator": "leapfrog", "includes": [{"path": "sub.json"}]}`,
+		"sub.json": `{"name": "s", "dt": 1, "integrator": "euler", "softening": 2, "escape": {"radius": 10},
+			"boundary": {"type": "reflect", "min": [0, 0], "max": [10, 10]},
+			"bodies": [{"name": "a", "mass": 1, "pos": [5, 5], "radius": 1, "color": "#ffffff"}]}`,
+	})
+	issues, err := ValidateFS(fsys, "main.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, is := range issues {
+		if is.Severity == SeverityError {
+			t.Errorf("nieoczekiwany błąd: %s", is)
+		}
+		if strings.Contains(is.Message, "pomijane") {
+			got[is.Path] = true
+		}
+	}
+	for _, p := range []string{"includes[0].integrator", "includes[0].softening", "includes[0].escape", "includes[0].boundary"} {
+		if !got[p] {
+			t.Errorf("brak ostrzeżenia dla %s (%v)", p, issues)
+		}
+	}
+	if got["integrator"] {
+		t.Error("ostrzeżenie dla ustawienia pliku głównego")
+	}
+}
@@ -0,0 +1,134 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Observer - obiekt powiadamiany o krokach symulacji (zapis trajektorii, diagnostyka, detektory
+// zdarzeń, wykresy w GUI). OnStart jest wołane przy dołączeniu (Attach), OnStep po każdym kroku
+// z czasem t = s.Time, a OnStop przy odłączeniu. Błąd z OnStep odłącza obserwatora (z wywołaniem
+// OnStop) i jest zwracany przez Simulator.Err jako *ObserverError. Przeliczanie kroków przy
+// przewijaniu historii (SeekStep) nie powiadamia obserwatorów.
+type Observer interface {
+	OnStart(s *Simulator) error
+	OnStep(s *Simulator, t float64) error
+	OnStop(s *Simulator) error
+}
+
+// StepFunc - obserwator z samej funkcji OnStep (bez akcji przy starcie i zatrzymaniu)
+type StepFunc func(s *Simulator, t float64) error
+
+func (f StepFunc) OnStart(*Simulator) error { return nil }
+
+func (f StepFunc) OnStep(s *Simulator, t float64) error { return f(s, t) }
+
+func (f StepFunc) OnStop(*Simulator) error { return nil }
+
+// every - obserwator wołany co k kroków
+type every struct {
+	k int64
+	Observer
+}
+
+// Every zwraca obserwatora, który przekazuje do o tylko kroki podzielne przez k
+// (k <= 1 - każdy krok); OnStart i OnStop są przekazywane bez zmian
+func Every(k int, o Observer) Observer {
+	if k <= 1 {
+		return o
+	}
+	return &every{k: int64(k), Observer: o}
+}
+
+func (e *every) OnStep(s *Simulator, t float64) error {
+	if s.Step%e.k != 0 {
+		return nil
+	}
+	return e.Observer.OnStep(s, t)
+}
+
+// ObserverError - błąd obserwatora, który został przez to odłączony
+type ObserverError struct {
+	Observer Observer
+	Err      error
+}
+
+func (e *ObserverError) Error() string { return e.Err.Error() }
+
+func (e *ObserverError) Unwrap() error { return e.Err }
+
+// observerEntry - dołączony obserwator z identyfikatorem (obserwatorzy nie muszą być porównywalni)
+type observerEntry struct {
+	id int
+	o  Observer
+}
+
+// Attach wywołuje OnStart obserwatora i dołącza go (przy błędzie obserwator nie jest dołączany).
+// Zwrócona funkcja odłącza obserwatora i woła jego OnStop (kolejne wywołania nic nie robią).
+func (s *Simulator) Attach(o Observer) (detach func() error, err error) {
+	if err := o.OnStart(s); err != nil {
+		return nil, err
+	}
+	e := observerEntry{id: s.nextObserver, o: o}
+	s.nextObserver++
+	s.observers = append(s.observers, e)
+	return func() error { return s.detach(e.id) }, nil
+}
+
+func (s *Simulator) detach(id int) error {
+	for i, e := range s.observers {
+		if e.id == id {
+			s.observers = append(s.observers[:i:i], s.observers[i+1:]...)
+			return e.o.OnStop(s)
+		}
+	}
+	return nil
+}
+
+// Err zwraca pierwszy błąd obserwatora od poprzedniego wywołania Err (nil, gdy go nie było)
+func (s *Simulator) Err() error {
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// notifyStep woła OnStep wszystkich obserwatorów; obserwatorzy z błędem są odłączani
+func (s *Simulator) notifyStep() {
+	// kopia listy: obserwator może dołączać lub odłączać innych
+	for _, e := range append([]observerEntry(nil), s.observers...) {
+		if !s.attached(e.id) {
+			continue
+		}
+		err := e.o.OnStep(s, s.Time)
+		if err == nil {
+			continue
+		}
+		if stopErr := s.detach(e.id); stopErr != nil {
+			err = errors.Join(err, stopErr)
+		}
+		if s.err == nil {
+			s.err = &ObserverError{Observer: e.o, Err: fmt.Errorf("krok %d: %w", s.Step, err)}
+		}
+	}
+}
+
+func (s *Simulator) attached(id int) bool {
+	for _, e := range s.observers {
+		if e.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// eventDetector - wbudowany obserwator uruchamiający detektory zdarzeń (patrz detectEvents)
+type eventDetector struct{}
+
+func (eventDetector) OnStart(*Simulator) error { return nil }
+
+func (eventDetector) OnStep(s *Simulator, _ float64) error {
+	s.detectEvents()
+	return nil
+}
+
+func (eventDetector) OnStop(*Simulator) error { return nil }
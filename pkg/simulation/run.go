@@ -0,0 +1,100 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+)
+
+// Until - warunki zatrzymania Run; liczy się pierwszy spełniony, pola zerowe są pomijane.
+// Bez żadnego warunku Run liczy aż do anulowania kontekstu.
+type Until struct {
+	Time      float64               // czas symulacji (bezwzględny), do którego liczyć
+	Steps     int64                 // liczba kroków do wykonania w tym wywołaniu
+	Predicate func(*Simulator) bool // sprawdzany po każdym kroku; true zatrzymuje symulację
+}
+
+// StopReason - powód zatrzymania Run
+type StopReason int
+
+const (
+	StopTime      StopReason = iota + 1 // osiągnięto Until.Time
+	StopSteps                           // wykonano Until.Steps kroków
+	StopPredicate                       // Until.Predicate zwrócił true
+	StopCanceled                        // kontekst anulowany lub minął jego termin
+	StopError                           // błąd obserwatora albo warunku zatrzymania
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StopTime:
+		return "time"
+	case StopSteps:
+		return "steps"
+	case StopPredicate:
+		return "predicate"
+	case StopCanceled:
+		return "canceled"
+	case StopError:
+		return "error"
+	}
+	return fmt.Sprintf("StopReason(%d)", int(r))
+}
+
+// RunResult - wynik Run
+type RunResult struct {
+	Steps       int64 // liczba wykonanych kroków
+	Time        float64
+	Reason      StopReason
+	Diagnostics Diagnostics // stan układu po zatrzymaniu
+}
+
+// Run wykonuje kroki, aż spełni się jeden z warunków until, kontekst zostanie anulowany
+// albo obserwator zwróci błąd. Przy anulowaniu zwraca ctx.Err() (context.Canceled lub
+// context.DeadlineExceeded), przy błędzie obserwatora ten błąd; wynik jest wypełniony zawsze.
+// Czas jest porównywany z tolerancją ułamka kroku, aby sumowanie dt nie dodawało kroku.
+// Warunek Until.Time przy dt <= 0 nigdy by się nie spełnił, więc Run od razu zwraca błąd.
+func (s *Simulator) Run(ctx context.Context, until Until) (RunResult, error) {
+	done := ctx.Done()
+	var res RunResult
+	var err error
+	if until.Time > 0 && !(s.Dt > 0) {
+		res.Reason, err = StopError, fmt.Errorf("warunek czasu %g wymaga dodatniego dt (dt = %g)", until.Time, s.Dt)
+	}
+	for err == nil {
+		if res.Reason = s.stopReason(until, res.Steps); res.Reason != 0 {
+			break
+		}
+		select {
+		case <-done:
+			res.Reason, err = StopCanceled, ctx.Err()
+		default:
+		}
+		if err != nil {
+			break
+		}
+		s.Update()
+		res.Steps++
+		if err = s.Err(); err != nil {
+			res.Reason = StopError
+			break
+		}
+		if until.Predicate != nil && until.Predicate(s) {
+			res.Reason = StopPredicate
+			break
+		}
+	}
+	res.Time = s.Time
+	res.Diagnostics = s.Diagnostics()
+	return res, err
+}
+
+// stopReason sprawdza warunki czasu i liczby kroków (0 = jeszcze nie koniec)
+func (s *Simulator) stopReason(until Until, steps int64) StopReason {
+	switch {
+	case until.Steps > 0 && steps >= until.Steps:
+		return StopSteps
+	case until.Time > 0 && s.Time >= until.Time-1e-9*s.Dt:
+		return StopTime
+	}
+	return 0
+}
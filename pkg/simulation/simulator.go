@@ -1,7 +1,12 @@
 package simulation
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"gravity-sim/pkg/physics"
+	"gravity-sim/pkg/units"
 )
 
 // --- Główna struktura symulatora ---
@@ -9,6 +14,41 @@ type Simulator struct {
 	Name   string
 	Dt     float64
 	Bodies []physics.Body
+
+	// Integrator - nazwa metody całkowania z physics.Integrators
+	Integrator string
+
+	// Boundary - warunki brzegowe (nil = otwarta przestrzeń)
+	Boundary *physics.Boundary
+
+	// Units - układ jednostek sceny (zerowy = jednostki umowne); G i Softening są w tych jednostkach
+	Units     units.System
+	G         float64
+	Softening float64
+
+	Time float64 // czas symulacji
+	Step int64   // liczba wykonanych kroków
+
+	// Escape - wykrywanie ucieczek (nil = wyłączone)
+	Escape *EscapeConfig
+	// CloseEncounter - próg odległości dla zdarzeń bliskiego przejścia (0 = wyłączone)
+	CloseEncounter float64
+
+	// Events - szyna zdarzeń (kolizje, ucieczki, bliskie przejścia, apsydy, NaN)
+	Events *EventBus
+	detect detectorState
+
+	// History - bufor przeszłych stanów do przewijania (nil = wyłączony, patrz EnableHistory)
+	History   *History
+	replaying bool // przeliczanie kroków podczas SeekStep: bez zdarzeń i bez zapisu historii
+
+	ids    map[string]int // Body.ID -> indeks w Bodies (odbudowywana, gdy jest nieaktualna)
+	nextID int            // numer następnego automatycznego identyfikatora "#n"
+
+	// obserwatorzy kroków (patrz Attach); pierwszy to wbudowany detektor zdarzeń
+	observers    []observerEntry
+	nextObserver int
+	err          error // pierwszy błąd obserwatora od ostatniego Err
 }
 
 // --- Tworzenie symulatora z konfiguracji ---
@@ -16,23 +56,172 @@ func NewSimulator(cfg EnvironmentConfig) *Simulator {
 	bodies := make([]physics.Body, len(cfg.Bodies))
 
 	for i, b := range cfg.Bodies {
-		bodies[i] = physics.Body{
-			Mass:   b.Mass,
-			Pos:    physics.Vec2{X: b.Pos[0], Y: b.Pos[1]},
-			Vel:    physics.Vec2{X: b.Vel[0], Y: b.Vel[1]},
-			Radius: b.Radius,
-			ColorC: parseColor(b.Color), // parseColor zwraca teraz color.RGBA
-		}
+		bodies[i] = b.Body()
 	}
 
-	return &Simulator{
-		Name:   cfg.Name,
-		Dt:     cfg.Dt,
-		Bodies: bodies,
+	// błędny blok units zgłasza LoadConfig; tutaj zostają jednostki umowne
+	sys, _ := cfg.Units.toUnits()
+	sim := &Simulator{
+		Name:       cfg.Name,
+		Dt:         cfg.Dt,
+		Bodies:     bodies,
+		Integrator: cfg.Integrator,
+		Units:      sys,
+		G:          cfg.G(),
+		Softening:  cfg.EffectiveSoftening(),
+		Escape:     cfg.Escape,
+		Events:     &EventBus{},
 	}
+	sim.Attach(eventDetector{})
+	if sim.Integrator == "" {
+		sim.Integrator = physics.DefaultIntegrator
+	}
+	if cfg.Events != nil {
+		sim.CloseEncounter = cfg.Events.CloseEncounter
+	}
+	sim.assignIDs()
+	return sim
+}
+
+// Params zwraca parametry oddziaływania symulatora (G, softening, warunki brzegowe)
+func (s *Simulator) Params() *physics.Params {
+	return &physics.Params{G: s.G, Softening: s.Softening, Boundary: s.Boundary}
 }
 
 // --- Aktualizacja symulacji ---
 func (s *Simulator) Update() {
-	s.Bodies = physics.IntegrateEulerSymplectic(s.Bodies, s.Dt)
+	integrate, ok := physics.Integrators[s.Integrator]
+	if !ok {
+		integrate = physics.Integrators[physics.DefaultIntegrator]
+	}
+	s.Bodies = integrate(s.Bodies, s.Dt, s.Params())
+	s.Time += s.Dt
+	s.Step++
+
+	// ucieczki z usuwaniem ciał zmieniają przebieg symulacji, więc są sprawdzane także przy przewijaniu
+	if s.Escape != nil {
+		every := int64(s.Escape.CheckEvery)
+		if every <= 0 {
+			every = 10
+		}
+		if s.Step%every == 0 {
+			s.detectEscapes()
+		}
+	}
+	if s.replaying {
+		return
+	}
+	if s.History != nil {
+		every := int64(s.History.KeyframeEvery)
+		if every <= 0 {
+			every = 1 // jak w NewHistory
+		}
+		if s.Step%every == 0 {
+			s.History.record(s)
+		}
+	}
+	s.notifyStep()
+}
+
+// Advance wykonuje n kroków symulacji (błędy obserwatorów zwraca Err)
+func (s *Simulator) Advance(n int) {
+	for i := 0; i < n; i++ {
+		s.Update()
+	}
+}
+
+// --- Identyfikatory ciał ---
+
+// assignIDs nadaje identyfikatory ciałom, które ich nie mają: nazwę ciała, jeśli nie jest zajęta,
+// a w przeciwnym razie "#i", gdzie i to pozycja ciała (dla ciał z pliku sceny - indeks w "bodies").
+// Licznik automatycznych identyfikatorów jest przesuwany za wszystkie istniejące "#n".
+func (s *Simulator) assignIDs() {
+	taken := make(map[string]bool, len(s.Bodies))
+	for _, b := range s.Bodies {
+		taken[b.ID] = b.ID != ""
+		if num, ok := strings.CutPrefix(b.ID, "#"); ok {
+			if n, err := strconv.Atoi(num); err == nil {
+				s.nextID = max(s.nextID, n+1)
+			}
+		}
+	}
+	s.nextID = max(s.nextID, len(s.Bodies))
+	for i := range s.Bodies {
+		b := &s.Bodies[i]
+		if b.ID != "" {
+			continue
+		}
+		switch id := fmt.Sprintf("#%d", i); {
+		case b.Name != "" && !taken[b.Name]:
+			b.ID = b.Name
+		case !taken[id]:
+			b.ID = id
+		default:
+			b.ID = s.autoID(func(id string) bool { return taken[id] })
+		}
+		taken[b.ID] = true
+	}
+	s.ids = nil
+}
+
+// autoID zwraca kolejny identyfikator "#n", który nie jest zajęty; numery nie są używane ponownie
+func (s *Simulator) autoID(taken func(string) bool) string {
+	for {
+		id := fmt.Sprintf("#%d", s.nextID)
+		s.nextID++
+		if !taken(id) {
+			return id
+		}
+	}
+}
+
+// Index zwraca indeks ciała o identyfikatorze id w Bodies (-1, gdy takiego ciała nie ma)
+func (s *Simulator) Index(id string) int {
+	if i, ok := s.ids[id]; ok && i < len(s.Bodies) && s.Bodies[i].ID == id {
+		return i
+	}
+	s.ids = make(map[string]int, len(s.Bodies))
+	for i, b := range s.Bodies {
+		s.ids[b.ID] = i
+	}
+	if i, ok := s.ids[id]; ok {
+		return i
+	}
+	return -1
+}
+
+// Body zwraca ciało o identyfikatorze id (nil, gdy takiego ciała nie ma)
+func (s *Simulator) Body(id string) *physics.Body {
+	if i := s.Index(id); i >= 0 {
+		return &s.Bodies[i]
+	}
+	return nil
+}
+
+// AddBody dodaje ciało na końcu listy i zwraca jego identyfikator. Ciało bez ID dostaje
+// nazwę (jeśli jest wolna) albo nowy identyfikator "#n"; zajęte ID jest błędem.
+func (s *Simulator) AddBody(b physics.Body) (string, error) {
+	taken := func(id string) bool { return s.Index(id) >= 0 }
+	switch {
+	case b.ID != "":
+		if taken(b.ID) {
+			return "", fmt.Errorf("identyfikator %q jest już zajęty", b.ID)
+		}
+	case b.Name != "" && !taken(b.Name):
+		b.ID = b.Name
+	default:
+		s.nextID = max(s.nextID, len(s.Bodies))
+		b.ID = s.autoID(taken)
+	}
+	s.Bodies = append(s.Bodies, b)
+	return b.ID, nil
+}
+
+// RemoveBody usuwa ciało o indeksie i, zachowując kolejność pozostałych
+func (s *Simulator) RemoveBody(i int) {
+	if i < 0 || i >= len(s.Bodies) {
+		return
+	}
+	s.detect.forget(s.Bodies[i].ID)
+	s.Bodies = append(s.Bodies[:i], s.Bodies[i+1:]...)
 }
@@ -0,0 +1,244 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+)
+
+// maxTemplateBodies - górny limit liczby ciał z jednego szablonu (chroni przed literówką w "count")
+const maxTemplateBodies = 100000
+
+// TemplateConfig - szablon rozwijany przy wczytywaniu sceny w wiele ciał;
+// ustawione musi być dokładnie jedno z pól
+type TemplateConfig struct {
+	Ring   *RingTemplate   `json:"ring,omitempty"`
+	Grid   *GridTemplate   `json:"grid,omitempty"`
+	Random *RandomTemplate `json:"random,omitempty"`
+}
+
+// TemplateBody - pola wspólne szablonów: wygląd generowanych ciał i położenie szablonu
+type TemplateBody struct {
+	Name       string     `json:"name,omitempty"`        // przedrostek nazw: "moon" daje moon-1, moon-2...
+	Mass       float64    `json:"mass"`                  // masa każdego ciała
+	BodyRadius float64    `json:"body_radius,omitempty"` // promień każdego ciała (domyślnie 2)
+	Color      string     `json:"color,omitempty"`
+	Locked     bool       `json:"locked,omitempty"`
+	Anti       bool       `json:"anti,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Center     string     `json:"center,omitempty"`     // nazwa ciała, względem którego leży szablon (pozycja i prędkość)
+	Offset     [2]float64 `json:"offset,omitempty"`     // przesunięcie środka szablonu względem center (lub początku układu)
+	Vel        [2]float64 `json:"vel,omitempty"`        // prędkość dodawana wszystkim ciałom
+	Orbit      bool       `json:"orbit,omitempty"`      // prędkość orbity kołowej wokół ciała center
+	Retrograde bool       `json:"retrograde,omitempty"` // orbita zgodna z ruchem wskazówek zegara
+}
+
+// RingTemplate - Count ciał rozmieszczonych równomiernie na okręgu
+type RingTemplate struct {
+	TemplateBody
+	Count  int     `json:"count"`
+	Radius float64 `json:"radius"`          // promień okręgu
+	Phase  float64 `json:"phase,omitempty"` // kąt pierwszego ciała, w stopniach
+}
+
+// GridTemplate - prostokątna siatka Rows x Cols ciał o środku w center + offset
+type GridTemplate struct {
+	TemplateBody
+	Rows    int     `json:"rows"`
+	Cols    int     `json:"cols"`
+	Spacing float64 `json:"spacing"` // odległość sąsiednich ciał
+}
+
+// RandomTemplate - Count ciał rozłożonych losowo; to samo ziarno daje zawsze te same pozycje
+type RandomTemplate struct {
+	TemplateBody
+	Count        int     `json:"count"`
+	Distribution string  `json:"distribution,omitempty"` // "uniform" (domyślnie, koło o promieniu Radius) lub "gaussian" (odchylenie Radius)
+	Radius       float64 `json:"radius"`
+	Seed         uint64  `json:"seed,omitempty"`
+}
+
+// expand rozwija szablon w ciała; ciało center jest szukane po nazwie wśród bodies
+func (t TemplateConfig) expand(bodies []BodyConfig, G float64) ([]BodyConfig, error) {
+	set := 0
+	for _, ok := range []bool{t.Ring != nil, t.Grid != nil, t.Random != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("szablon musi mieć dokładnie jedno z pól ring, grid, random")
+	}
+	var (
+		tb  TemplateBody
+		pos [][2]float64
+		err error
+	)
+	switch {
+	case t.Ring != nil:
+		tb = t.Ring.TemplateBody
+		pos, err = t.Ring.positions()
+	case t.Grid != nil:
+		tb = t.Grid.TemplateBody
+		pos, err = t.Grid.positions()
+	default:
+		tb = t.Random.TemplateBody
+		pos, err = t.Random.positions()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tb.bodies(pos, bodies, G)
+}
+
+// bodies tworzy ciała w pozycjach pos (względem środka szablonu)
+func (tb TemplateBody) bodies(pos [][2]float64, scene []BodyConfig, G float64) ([]BodyConfig, error) {
+	if tb.Mass < 0 {
+		return nil, fmt.Errorf("masa nie może być ujemna (%g)", tb.Mass)
+	}
+	var center *BodyConfig
+	if tb.Center != "" {
+		for i := range scene {
+			if scene[i].Name == tb.Center {
+				center = &scene[i]
+				break
+			}
+		}
+		if center == nil {
+			return nil, fmt.Errorf("center: nie znaleziono ciała o nazwie %q", tb.Center)
+		}
+	} else if tb.Orbit {
+		return nil, fmt.Errorf("orbit wymaga ciała centralnego (center)")
+	}
+	radius := tb.BodyRadius
+	if radius <= 0 {
+		radius = 2
+	}
+
+	out := make([]BodyConfig, len(pos))
+	for i, p := range pos {
+		b := BodyConfig{
+			Mass:   tb.Mass,
+			Pos:    [2]float64{tb.Offset[0] + p[0], tb.Offset[1] + p[1]},
+			Vel:    tb.Vel,
+			Color:  tb.Color,
+			Radius: radius,
+			Locked: tb.Locked,
+			Anti:   tb.Anti,
+			Tags:   tb.Tags,
+		}
+		if tb.Name != "" {
+			b.Name = fmt.Sprintf("%s-%d", tb.Name, i+1)
+		}
+		if center != nil {
+			if tb.Orbit {
+				// prędkość kołowa wokół ciała centralnego, prostopadle do wektora od niego
+				dx, dy := b.Pos[0], b.Pos[1]
+				if r := math.Hypot(dx, dy); r > 0 {
+					v := math.Sqrt(G * center.Mass / r)
+					if tb.Retrograde {
+						v = -v
+					}
+					b.Vel[0] += -dy / r * v
+					b.Vel[1] += dx / r * v
+				}
+			}
+			b.Pos[0] += center.Pos[0]
+			b.Pos[1] += center.Pos[1]
+			b.Vel[0] += center.Vel[0]
+			b.Vel[1] += center.Vel[1]
+		}
+		out[i] = b
+	}
+	return out, nil
+}
+
+// checkCount sprawdza liczbę ciał szablonu; dla siatki jest to iloczyn wymiarów, które
+// sprawdzane są osobno, aby iloczyn nie przepełnił int
+func checkCount(dims ...int) error {
+	n := 1
+	for _, d := range dims {
+		if d <= 0 || d > maxTemplateBodies {
+			return fmt.Errorf("liczba ciał musi być w zakresie 1..%d (jest %d)", maxTemplateBodies, d)
+		}
+		if n *= d; n > maxTemplateBodies {
+			return fmt.Errorf("liczba ciał musi być w zakresie 1..%d (jest %s)", maxTemplateBodies, dimsString(dims))
+		}
+	}
+	return nil
+}
+
+func dimsString(dims []int) string {
+	s := make([]string, len(dims))
+	for i, d := range dims {
+		s[i] = fmt.Sprint(d)
+	}
+	return strings.Join(s, " x ")
+}
+
+func (t *RingTemplate) positions() ([][2]float64, error) {
+	if err := checkCount(t.Count); err != nil {
+		return nil, fmt.Errorf("ring: %v", err)
+	}
+	if t.Radius <= 0 {
+		return nil, fmt.Errorf("ring: promień musi być dodatni (%g)", t.Radius)
+	}
+	pos := make([][2]float64, t.Count)
+	for i := range pos {
+		phi := t.Phase*math.Pi/180 + 2*math.Pi*float64(i)/float64(t.Count)
+		pos[i] = [2]float64{t.Radius * math.Cos(phi), t.Radius * math.Sin(phi)}
+	}
+	return pos, nil
+}
+
+func (t *GridTemplate) positions() ([][2]float64, error) {
+	if t.Rows <= 0 || t.Cols <= 0 {
+		return nil, fmt.Errorf("grid: rows i cols muszą być dodatnie (%d x %d)", t.Rows, t.Cols)
+	}
+	if err := checkCount(t.Rows, t.Cols); err != nil {
+		return nil, fmt.Errorf("grid: %v", err)
+	}
+	if t.Spacing <= 0 {
+		return nil, fmt.Errorf("grid: odstęp musi być dodatni (%g)", t.Spacing)
+	}
+	// siatka wyśrodkowana na środku szablonu, wiersz po wierszu
+	pos := make([][2]float64, 0, t.Rows*t.Cols)
+	for r := 0; r < t.Rows; r++ {
+		for c := 0; c < t.Cols; c++ {
+			pos = append(pos, [2]float64{
+				(float64(c) - float64(t.Cols-1)/2) * t.Spacing,
+				(float64(r) - float64(t.Rows-1)/2) * t.Spacing,
+			})
+		}
+	}
+	return pos, nil
+}
+
+func (t *RandomTemplate) positions() ([][2]float64, error) {
+	if err := checkCount(t.Count); err != nil {
+		return nil, fmt.Errorf("random: %v", err)
+	}
+	if t.Radius <= 0 {
+		return nil, fmt.Errorf("random: promień musi być dodatni (%g)", t.Radius)
+	}
+	// ten sam generator co w pkg/generator, aby ziarno dawało powtarzalny wynik
+	rng := rand.New(rand.NewPCG(t.Seed, t.Seed^0x9e3779b97f4a7c15))
+	pos := make([][2]float64, t.Count)
+	switch t.Distribution {
+	case "", "uniform":
+		// rozkład jednorodny w kole
+		for i := range pos {
+			r := t.Radius * math.Sqrt(rng.Float64())
+			phi := 2 * math.Pi * rng.Float64()
+			pos[i] = [2]float64{r * math.Cos(phi), r * math.Sin(phi)}
+		}
+	case "gaussian":
+		for i := range pos {
+			pos[i] = [2]float64{t.Radius * rng.NormFloat64(), t.Radius * rng.NormFloat64()}
+		}
+	default:
+		return nil, fmt.Errorf("random: nieznany rozkład %q (uniform, gaussian)", t.Distribution)
+	}
+	return pos, nil
+}
@@ -0,0 +1,181 @@
+package simulation
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestTemplatePositions(t *testing.T) {
+	scene := []BodyConfig{{Name: "star", Mass: 100, Pos: [2]float64{1000, 0}, Vel: [2]float64{0, 5}}}
+	const G = 4.0
+	for _, tc := range []struct {
+		name string
+		tpl  TemplateConfig
+		pos  [][2]float64
+		vel  [][2]float64 // nil = nie sprawdzać
+	}{
+		{
+			name: "pierścień z fazą",
+			tpl:  TemplateConfig{Ring: &RingTemplate{Count: 4, Radius: 10, Phase: 90}},
+			pos:  [][2]float64{{0, 10}, {-10, 0}, {0, -10}, {10, 0}},
+		},
+		{
+			name: "siatka wyśrodkowana",
+			tpl:  TemplateConfig{Grid: &GridTemplate{Rows: 2, Cols: 3, Spacing: 2, TemplateBody: TemplateBody{Offset: [2]float64{0, 100}}}},
+			pos:  [][2]float64{{-2, 99}, {0, 99}, {2, 99}, {-2, 101}, {0, 101}, {2, 101}},
+		},
+		{
+			name: "orbita wokół center",
+			tpl: TemplateConfig{Ring: &RingTemplate{Count: 2, Radius: 25,
+				TemplateBody: TemplateBody{Center: "star", Orbit: true, Vel: [2]float64{1, 0}}}},
+			// v = sqrt(G M / r) = 4, przeciwnie do ruchu wskazówek, plus prędkość center i vel
+			pos: [][2]float64{{1025, 0}, {975, 0}},
+			vel: [][2]float64{{1, 9}, {1, 1}},
+		},
+		{
+			name: "orbita wsteczna",
+			tpl: TemplateConfig{Ring: &RingTemplate{Count: 1, Radius: 25,
+				TemplateBody: TemplateBody{Center: "star", Orbit: true, Retrograde: true}}},
+			pos: [][2]float64{{1025, 0}},
+			vel: [][2]float64{{0, 1}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bodies, err := tc.tpl.expand(scene, G)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(bodies) != len(tc.pos) {
+				t.Fatalf("%d ciał, oczekiwano %d", len(bodies), len(tc.pos))
+			}
+			for i, b := range bodies {
+				if !near(b.Pos[0], tc.pos[i][0]) || !near(b.Pos[1], tc.pos[i][1]) {
+					t.Errorf("ciało %d: pozycja %v, oczekiwano %v", i, b.Pos, tc.pos[i])
+				}
+				if tc.vel != nil && (!near(b.Vel[0], tc.vel[i][0]) || !near(b.Vel[1], tc.vel[i][1])) {
+					t.Errorf("ciało %d: prędkość %v, oczekiwano %v", i, b.Vel, tc.vel[i])
+				}
+				if b.Radius != 2 {
+					t.Errorf("ciało %d: promień %g, oczekiwano domyślnego 2", i, b.Radius)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		tpl  TemplateConfig
+		err  string
+	}{
+		{"brak rodzaju", TemplateConfig{}, "dokładnie jedno z pól"},
+		{"dwa rodzaje", TemplateConfig{Ring: &RingTemplate{Count: 1, Radius: 1}, Grid: &GridTemplate{Rows: 1, Cols: 1, Spacing: 1}}, "dokładnie jedno z pól"},
+		{"pusty pierścień", TemplateConfig{Ring: &RingTemplate{Radius: 1}}, "ring: liczba ciał musi być w zakresie"},
+		{"za duży pierścień", TemplateConfig{Ring: &RingTemplate{Count: maxTemplateBodies + 1, Radius: 1}}, "ring: liczba ciał"},
+		// każdy wymiar mieści się w limicie, iloczyn już nie
+		{"iloczyn siatki", TemplateConfig{Grid: &GridTemplate{Rows: 1000, Cols: 1000, Spacing: 1}}, "(jest 1000 x 1000)"},
+		// iloczyn nie może przepełnić int ani zaalokować pamięci przed sprawdzeniem
+		{"ogromna siatka", TemplateConfig{Grid: &GridTemplate{Rows: maxTemplateBodies, Cols: maxTemplateBodies, Spacing: 1}}, "grid: liczba ciał"},
+		{"ujemny wymiar", TemplateConfig{Grid: &GridTemplate{Rows: -1, Cols: 5, Spacing: 1}}, "rows i cols muszą być dodatnie"},
+		{"odstęp 0", TemplateConfig{Grid: &GridTemplate{Rows: 1, Cols: 1}}, "grid: odstęp musi być dodatni"},
+		{"nieznany rozkład", TemplateConfig{Random: &RandomTemplate{Count: 1, Radius: 1, Distribution: "poisson"}}, `nieznany rozkład "poisson"`},
+		{"brak center", TemplateConfig{Ring: &RingTemplate{Count: 1, Radius: 1, TemplateBody: TemplateBody{Center: "nope"}}}, `nie znaleziono ciała o nazwie "nope"`},
+		{"orbit bez center", TemplateConfig{Ring: &RingTemplate{Count: 1, Radius: 1, TemplateBody: TemplateBody{Orbit: true}}}, "orbit wymaga ciała centralnego"},
+		{"ujemna masa", TemplateConfig{Ring: &RingTemplate{Count: 1, Radius: 1, TemplateBody: TemplateBody{Mass: -1}}}, "masa nie może być ujemna"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.tpl.expand(nil, 1)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("błąd %v, oczekiwano %q", err, tc.err)
+			}
+		})
+	}
+}
+
+// TestTemplateRandomSeed - to samo ziarno daje te same pozycje, inne ziarno - inne
+func TestTemplateRandomSeed(t *testing.T) {
+	expand := func(dist string, seed uint64) []BodyConfig {
+		t.Helper()
+		bodies, err := TemplateConfig{Random: &RandomTemplate{Count: 200, Radius: 50, Distribution: dist, Seed: seed}}.expand(nil, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bodies
+	}
+	for _, dist := range []string{"", "uniform", "gaussian"} {
+		a, b, c := expand(dist, 7), expand(dist, 7), expand(dist, 8)
+		same := 0
+		var sumR2 float64
+		for i := range a {
+			if a[i].Pos != b[i].Pos {
+				t.Fatalf("%q: ziarno 7 daje różne pozycje ciała %d: %v i %v", dist, i, a[i].Pos, b[i].Pos)
+			}
+			if a[i].Pos == c[i].Pos {
+				same++
+			}
+			r := math.Hypot(a[i].Pos[0], a[i].Pos[1])
+			if dist != "gaussian" && r > 50 {
+				t.Fatalf("%q: ciało %d poza kołem (r = %g)", dist, i, r)
+			}
+			sumR2 += r * r
+		}
+		if same == len(a) {
+			t.Errorf("%q: ziarna 7 i 8 dają te same pozycje", dist)
+		}
+		// średni kwadrat odległości: R²/2 dla koła, 2σ² dla rozkładu Gaussa
+		want := 50.0 * 50 / 2
+		if dist == "gaussian" {
+			want = 2 * 50 * 50
+		}
+		if got := sumR2 / float64(len(a)); math.Abs(got/want-1) > 0.25 {
+			t.Errorf("%q: średni kwadrat odległości %g, oczekiwano około %g", dist, got, want)
+		}
+	}
+}
+
+// TestTemplateScene - szablony w pliku sceny: nazwy, center z dołączonej sceny i z wcześniejszego
+// szablonu oraz limit iloczynu siatki zgłaszany przez walidację
+func TestTemplateScene(t *testing.T) {
+	fsys := sceneFS(map[string]string{
+		"main.json": `{"name": "m", "dt": 1, "includes": [{"path": "star.json", "offset": [100, 0]}], "templates": [
+			{"ring": {"name": "moon", "count": 3, "radius": 10, "mass": 1, "center": "star", "orbit": true}},
+			{"grid": {"name": "dust", "rows": 2, "cols": 2, "spacing": 1, "mass": 0.1, "center": "moon-2"}}]}`,
+		"star.json": `{"name": "s", "dt": 1, "bodies": [{"name": "star", "mass": 1000, "pos": [0, 0], "radius": 5, "color": "#ffffff"}]}`,
+		"big.json": `{"name": "big", "dt": 1, "templates": [{"grid": {"rows": 1000, "cols": 1000, "spacing": 1, "mass": 1}},
+			{"ring": {"count": 5, "radius": 1, "mass": 1, "center": "missing"}}]}`,
+	})
+	env, err := LoadEnvironmentFS(fsys, "main.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, b := range env.Bodies {
+		names = append(names, b.Name)
+	}
+	if got := strings.Join(names, " "); got != "star moon-1 moon-2 moon-3 dust-1 dust-2 dust-3 dust-4" {
+		t.Fatalf("ciała sceny: %s", got)
+	}
+	if len(env.Templates) != 0 {
+		t.Fatal("szablony nie zostały usunięte ze spłaszczonej sceny")
+	}
+	moon2 := env.Bodies[2]
+	for _, d := range env.Bodies[5:] {
+		if math.Abs(d.Pos[0]-moon2.Pos[0]) != 0.5 || d.Vel != moon2.Vel {
+			t.Errorf("%s: pozycja %v, prędkość %v nie odpowiada ciału center %v, %v", d.Name, d.Pos, d.Vel, moon2.Pos, moon2.Vel)
+		}
+	}
+
+	if _, err := LoadEnvironmentFS(fsys, "big.json"); err == nil || !strings.Contains(err.Error(), "templates[0]") {
+		t.Fatalf("błąd %v, oczekiwano błędu templates[0]", err)
+	}
+	issues, err := ValidateFS(fsys, "big.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIssues(t, issues, []Issue{
+		{"templates[0].grid", SeverityError, "szablon daje więcej niż 100000 ciał"},
+		{"templates[1].ring.center", SeverityError, `nie znaleziono ciała o nazwie "missing"`},
+	})
+}
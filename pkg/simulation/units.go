@@ -0,0 +1,102 @@
+package simulation
+
+import (
+	"fmt"
+
+	"gravity-sim/pkg/physics"
+	"gravity-sim/pkg/units"
+)
+
+// UnitsConfig - blok "units" sceny: jednostki długości, masy i czasu (np. "AU", "Msun", "yr"
+// lub "0.01 AU") albo gotowy układ "system": "henon". Stała G jest z nich wyprowadzana.
+// Scena bez tego bloku używa jednostek umownych z G = physics.G.
+type UnitsConfig struct {
+	System string `json:"system,omitempty"` // "henon" - jednostki N-ciałowe Hénona (G = 1)
+	Length string `json:"length,omitempty"`
+	Mass   string `json:"mass,omitempty"`
+	Time   string `json:"time,omitempty"`
+}
+
+// toUnits zamienia blok units na układ jednostek (nil = jednostki umowne)
+func (u *UnitsConfig) toUnits() (units.System, error) {
+	if u == nil {
+		return units.System{}, nil
+	}
+	switch u.System {
+	case "":
+		return units.New(u.Length, u.Mass, u.Time)
+	case "henon":
+		if u.Length != "" || u.Mass != "" || u.Time != "" {
+			return units.System{}, fmt.Errorf("\"system\": \"henon\" nie łączy się z length/mass/time")
+		}
+		return units.Henon(), nil
+	}
+	return units.System{}, fmt.Errorf("nieznany układ jednostek %q (henon)", u.System)
+}
+
+// unitsConfig zamienia układ jednostek z powrotem na blok units (nil dla jednostek umownych)
+func unitsConfig(s units.System) *UnitsConfig {
+	switch {
+	case s.Henon:
+		return &UnitsConfig{System: "henon"}
+	case s.IsZero():
+		return nil
+	}
+	return &UnitsConfig{Length: s.Length.Name, Mass: s.Mass.Name, Time: s.Time.Name}
+}
+
+// UnitSystem zwraca układ jednostek sceny (zerowy dla jednostek umownych)
+func (env EnvironmentConfig) UnitSystem() (units.System, error) {
+	return env.Units.toUnits()
+}
+
+// G zwraca stałą grawitacji w jednostkach sceny
+func (env EnvironmentConfig) G() float64 {
+	return gravConstant(env.Units)
+}
+
+func gravConstant(u *UnitsConfig) float64 {
+	sys, err := u.toUnits()
+	if err != nil || sys.IsZero() {
+		return physics.G
+	}
+	return sys.G()
+}
+
+// EffectiveSoftening zwraca softening sceny: jawny albo domyślny (physics.Softening dla jednostek
+// umownych, 0 - czysta grawitacja Newtona - dla jednostek fizycznych)
+func (env EnvironmentConfig) EffectiveSoftening() float64 {
+	switch {
+	case env.Softening != nil:
+		return *env.Softening
+	case env.Units != nil:
+		return 0
+	}
+	return physics.Softening
+}
+
+// convertBodies przelicza ciała z układu from do układu to
+func convertBodies(bodies []BodyConfig, from, to units.System) error {
+	conv := func(v *float64, d units.Dim) error {
+		c, err := from.Convert(*v, d, to)
+		*v = c
+		return err
+	}
+	for i := range bodies {
+		b := &bodies[i]
+		for _, f := range []struct {
+			v *float64
+			d units.Dim
+		}{
+			{&b.Mass, units.Mass},
+			{&b.Pos[0], units.Length}, {&b.Pos[1], units.Length},
+			{&b.Vel[0], units.Velocity}, {&b.Vel[1], units.Velocity},
+			{&b.Radius, units.Length},
+		} {
+			if err := conv(f.v, f.d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
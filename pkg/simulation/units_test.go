@@ -0,0 +1,133 @@
+package simulation
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"gravity-sim/pkg/physics"
+	"gravity-sim/pkg/units"
+)
+
+func TestUnitsConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  *UnitsConfig
+		g    float64 // oczekiwana stała G sceny
+		err  string
+	}{
+		{"brak bloku", nil, physics.G, ""},
+		{"AU/Msun/yr", &UnitsConfig{Length: "AU", Mass: "Msun", Time: "yr"}, 4 * math.Pi * math.Pi, ""},
+		{"wielkość liter", &UnitsConfig{Length: "au", Mass: "MSUN", Time: "Yr"}, 4 * math.Pi * math.Pi, ""},
+		{"jednostka skalowana", &UnitsConfig{Length: "0.01 AU", Mass: "Msun", Time: "yr"}, 4 * math.Pi * math.Pi * 1e6, ""},
+		{"SI", &UnitsConfig{Length: "m", Mass: "kg", Time: "s"}, units.GSI, ""},
+		{"henon", &UnitsConfig{System: "henon"}, 1, ""},
+		{"henon z jednostkami", &UnitsConfig{System: "henon", Length: "AU"}, 0, "nie łączy się"},
+		{"nieznany układ", &UnitsConfig{System: "cgs"}, 0, "nieznany układ jednostek"},
+		{"nieznana jednostka", &UnitsConfig{Length: "furlong", Mass: "kg", Time: "s"}, 0, "nieznana jednostka długości"},
+		{"brak jednostki", &UnitsConfig{Length: "AU", Mass: "Msun"}, 0, "brak jednostki czasu"},
+		{"ujemna skala", &UnitsConfig{Length: "-1 AU", Mass: "Msun", Time: "yr"}, 0, "niepoprawna jednostka"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sys, err := tc.cfg.toUnits()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("błąd %v, oczekiwano %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// rok juliański i GM☉ z DE440 dają G różne od 4π² na czwartym miejscu
+			if g := gravConstant(tc.cfg); math.Abs(g/tc.g-1) > 1e-3 {
+				t.Errorf("G = %g, oczekiwano %g", g, tc.g)
+			}
+			// blok units po przejściu przez układ jednostek opisuje ten sam układ
+			back, err := unitsConfig(sys).toUnits()
+			if err != nil || back != sys {
+				t.Errorf("unitsConfig(%v) = %v (%v)", sys, back, err)
+			}
+		})
+	}
+}
+
+func TestEffectiveSoftening(t *testing.T) {
+	two, zero := 2.0, 0.0
+	au := &UnitsConfig{Length: "AU", Mass: "Msun", Time: "yr"}
+	for _, tc := range []struct {
+		name string
+		env  EnvironmentConfig
+		want float64
+	}{
+		{"jednostki umowne", EnvironmentConfig{}, physics.Softening},
+		{"jednostki umowne, jawne 0", EnvironmentConfig{Softening: &zero}, 0},
+		{"blok units", EnvironmentConfig{Units: au}, 0},
+		{"henon", EnvironmentConfig{Units: &UnitsConfig{System: "henon"}}, 0},
+		{"blok units, jawny softening", EnvironmentConfig{Units: au, Softening: &two}, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.env.EffectiveSoftening(); got != tc.want {
+				t.Fatalf("EffectiveSoftening() = %g, oczekiwano %g", got, tc.want)
+			}
+			tc.env.Dt = 1
+			if sim := NewSimulator(tc.env); sim.Softening != tc.want || sim.G != tc.env.G() {
+				t.Fatalf("symulator: softening %g, G %g; oczekiwano %g, %g", sim.Softening, sim.G, tc.want, tc.env.G())
+			}
+		})
+	}
+}
+
+func TestConvertBodies(t *testing.T) {
+	mustUnits := func(l, m, tm string) units.System {
+		sys, err := units.New(l, m, tm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sys
+	}
+	yr := mustUnits("AU", "Msun", "yr")
+	for _, tc := range []struct {
+		name          string
+		to            units.System
+		length, speed float64 // mnożniki długości i prędkości
+		mass          float64
+		err           bool
+	}{
+		{"ten sam układ", yr, 1, 1, 1, false},
+		{"dni", mustUnits("AU", "Msun", "day"), 1, 1 / 365.25, 1, false},
+		{"jednostka skalowana", mustUnits("0.01 AU", "Msun", "yr"), 100, 100, 1, false},
+		{"masy Ziemi", mustUnits("AU", "Mearth", "yr"), 1, 1, 332946.0487, false},
+		{"jednostki umowne", units.System{}, 0, 0, 0, true},
+		{"henon", units.Henon(), 0, 0, 0, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			bodies := []BodyConfig{{Mass: 2, Pos: [2]float64{1, -3}, Vel: [2]float64{0.5, 6}, Radius: 0.01}}
+			err := convertBodies(bodies, yr, tc.to)
+			if tc.err {
+				if err == nil {
+					t.Fatal("brak błędu przeliczenia")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := bodies[0]
+			want := BodyConfig{
+				Mass:   2 * tc.mass,
+				Pos:    [2]float64{1 * tc.length, -3 * tc.length},
+				Vel:    [2]float64{0.5 * tc.speed, 6 * tc.speed},
+				Radius: 0.01 * tc.length,
+			}
+			for _, f := range [][2]float64{
+				{b.Mass, want.Mass}, {b.Pos[0], want.Pos[0]}, {b.Pos[1], want.Pos[1]},
+				{b.Vel[0], want.Vel[0]}, {b.Vel[1], want.Vel[1]}, {b.Radius, want.Radius},
+			} {
+				if math.Abs(f[0]/f[1]-1) > 1e-6 {
+					t.Fatalf("po przeliczeniu %+v, oczekiwano %+v", b, want)
+				}
+			}
+		})
+	}
+}
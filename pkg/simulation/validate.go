@@ -0,0 +1,778 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gravity-sim/pkg/physics"
+)
+
+// Severity - waga problemu w pliku sceny
+type Severity int
+
+const (
+	SeverityError   Severity = iota // scena nie zostanie wczytana
+	SeverityWarning                 // scena zadziała, ale prawdopodobnie nie tak, jak zamierzono
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Issue - problem w pliku sceny ze ścieżką JSON, np. "bodies[3].color"
+type Issue struct {
+	Path     string
+	Severity Severity
+	Message  string
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return i.Severity.String() + ": " + i.Message
+	}
+	return i.Path + ": " + i.Severity.String() + ": " + i.Message
+}
+
+// ValidationError - błędy walidacji zwracane przez LoadConfig
+type ValidationError struct {
+	Issues []Issue // tylko problemy o wadze SeverityError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Issues))
+	for i, is := range e.Issues {
+		parts[i] = is.Path + ": " + is.Message
+		if is.Path == "" {
+			parts[i] = is.Message
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// HasErrors mówi, czy wśród problemów są błędy
+func HasErrors(issues []Issue) bool {
+	for _, is := range issues {
+		if is.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate sprawdza treść jednego pliku sceny (bez wczytywania dołączonych scen):
+// składnię JSON, nieznane klucze, typy i zakresy wartości, kolory oraz nakładające się ciała.
+func Validate(data []byte) []Issue {
+	var root any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&root); err != nil {
+		var se *json.SyntaxError
+		if errors.As(err, &se) {
+			// Offset wskazuje za znak, który spowodował błąd
+			line, col := position(data, max(se.Offset-1, 0))
+			return []Issue{{Severity: SeverityError, Message: fmt.Sprintf("błąd składni JSON w linii %d, kolumnie %d: %v", line, col, err)}}
+		}
+		return []Issue{{Severity: SeverityError, Message: fmt.Sprintf("błąd parsowania JSON: %v", err)}}
+	}
+	v := &validator{}
+	v.scene(root)
+	return v.issues
+}
+
+// ValidateFile sprawdza plik sceny i rekurencyjnie sceny dołączone przez "includes";
+// ścieżki problemów w dołączonych plikach mają przedrostek "includes[i]".
+func ValidateFile(path string) ([]Issue, error) {
+	return validateFile(source{}, path, nil)
+}
+
+// ValidateFS działa jak ValidateFile dla pliku sceny w fsys
+func ValidateFS(fsys fs.FS, name string) ([]Issue, error) {
+	return validateFile(source{fsys}, name, nil)
+}
+
+func validateFile(src source, path string, stack []string) ([]Issue, error) {
+	abs, err := src.key(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range stack {
+		if p == abs {
+			return []Issue{{Severity: SeverityError, Message: "cykliczne dołączanie scen: " + strings.Join(append(stack, abs), " -> ")}}, nil
+		}
+	}
+	data, err := src.read(path)
+	if err != nil {
+		return nil, fmt.Errorf("błąd odczytu pliku: %v", err)
+	}
+	issues := Validate(data)
+	if HasErrors(issues) {
+		return issues, nil
+	}
+	var env EnvironmentConfig
+	if err := json.Unmarshal(data, &env); err != nil {
+		return issues, nil
+	}
+	if len(stack) > 0 {
+		issues = append(issues, ignoredInInclude(env)...)
+	}
+	for i, inc := range env.Includes {
+		prefix := fmt.Sprintf("includes[%d]", i)
+		sub, err := validateFile(src, src.include(path, inc.Path), append(stack, abs))
+		if err != nil {
+			issues = append(issues, Issue{Path: prefix + ".path", Severity: SeverityError, Message: err.Error()})
+			continue
+		}
+		for _, is := range sub {
+			is.Path = joinPath(prefix, is.Path)
+			issues = append(issues, is)
+		}
+		// jak w loadEnvironment: plik bez bloku units, ale z własnymi wartościami, nie przejmuje jednostek
+		if env.Units == nil && env.hasOwnValues() {
+			if subUnits := includedUnits(src, src.include(path, inc.Path)); subUnits != nil {
+				sys, _ := subUnits.toUnits()
+				issues = append(issues, Issue{Path: prefix + ".path", Severity: SeverityError,
+					Message: fmt.Sprintf("dołączona scena ma jednostki %s, a ten plik nie ma bloku units - dodaj go, aby określić jednostki własnych wartości", sys)})
+			}
+		}
+	}
+	return issues, nil
+}
+
+// includedUnits zwraca blok units dołączonej sceny (nil, gdy go nie ma lub pliku nie da się odczytać)
+func includedUnits(src source, path string) *UnitsConfig {
+	data, err := src.read(path)
+	if err != nil {
+		return nil
+	}
+	var env struct {
+		Units *UnitsConfig `json:"units"`
+	}
+	if json.Unmarshal(data, &env) != nil {
+		return nil
+	}
+	return env.Units
+}
+
+// ignoredInInclude ostrzega o ustawieniach dołączonej sceny, których spłaszczona scena nie
+// przejmuje - obowiązują ustawienia pliku, który ją dołącza (LoadEnvironment bierze z dołączonych
+// scen tylko ciała i dt)
+func ignoredInInclude(env EnvironmentConfig) []Issue {
+	var issues []Issue
+	for _, f := range []struct {
+		key string
+		set bool
+	}{
+		{"integrator", env.Integrator != ""},
+		{"boundary", env.Boundary != nil},
+		{"escape", env.Escape != nil},
+		{"events", env.Events != nil},
+		{"softening", env.Softening != nil},
+	} {
+		if f.set {
+			issues = append(issues, Issue{Path: f.key, Severity: SeverityWarning,
+				Message: "ustawienie dołączonej sceny jest pomijane - obowiązuje ustawienie pliku, który ją dołącza"})
+		}
+	}
+	return issues
+}
+
+func joinPath(prefix, path string) string {
+	switch {
+	case path == "":
+		return prefix
+	case prefix == "":
+		return path
+	}
+	return prefix + "." + path
+}
+
+// position zamienia offset w bajtach na linię i kolumnę (od 1)
+func position(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// --- Sprawdzanie poszczególnych sekcji ---
+
+type validator struct {
+	issues []Issue
+}
+
+func (v *validator) errorf(path, format string, args ...any) {
+	v.issues = append(v.issues, Issue{Path: path, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(path, format string, args ...any) {
+	v.issues = append(v.issues, Issue{Path: path, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+// field - znany klucz obiektu i funkcja sprawdzająca jego wartość
+type field struct {
+	key   string
+	check func(path string, val any)
+}
+
+// object sprawdza, że val jest obiektem, zgłasza nieznane klucze i sprawdza znane pola w kolejności fields.
+// Klucze są dopasowywane bez względu na wielkość liter (jak w encoding/json), ale inna pisownia to ostrzeżenie.
+func (v *validator) object(path string, val any, fields []field) map[string]any {
+	obj, ok := val.(map[string]any)
+	if !ok {
+		v.errorf(path, "oczekiwano obiektu, jest %s", typeName(val))
+		return nil
+	}
+	byKey := make(map[string]string, len(obj))
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		known := false
+		for _, f := range fields {
+			if strings.EqualFold(k, f.key) {
+				known = true
+				if k != f.key {
+					v.warnf(joinPath(path, k), "klucz powinien być zapisany jako %q", f.key)
+				}
+				byKey[f.key] = k
+			}
+		}
+		if !known {
+			v.errorf(joinPath(path, k), "nieznany klucz%s", suggest(k, fields))
+		}
+	}
+	for _, f := range fields {
+		if k, ok := byKey[f.key]; ok && f.check != nil {
+			f.check(joinPath(path, f.key), obj[k])
+		}
+	}
+	normalized := make(map[string]any, len(byKey))
+	for key, k := range byKey {
+		normalized[key] = obj[k]
+	}
+	return normalized
+}
+
+// suggest podpowiada znany klucz podobny do k
+func suggest(k string, fields []field) string {
+	lk := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(k))
+	for _, f := range fields {
+		if strings.ReplaceAll(f.key, "_", "") == lk {
+			return fmt.Sprintf(" (czy chodziło o %q?)", f.key)
+		}
+	}
+	return ""
+}
+
+func typeName(val any) string {
+	switch val.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "wartość logiczna"
+	case json.Number:
+		return "liczba"
+	case string:
+		return "tekst"
+	case []any:
+		return "tablica"
+	case map[string]any:
+		return "obiekt"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+func (v *validator) number(path string, val any) (float64, bool) {
+	n, ok := val.(json.Number)
+	if !ok {
+		v.errorf(path, "oczekiwano liczby, jest %s", typeName(val))
+		return 0, false
+	}
+	f, err := n.Float64()
+	if err != nil || math.IsInf(f, 0) {
+		v.errorf(path, "liczba poza zakresem: %s", n)
+		return 0, false
+	}
+	return f, true
+}
+
+func (v *validator) nonNegative(path string, val any) (float64, bool) {
+	f, ok := v.number(path, val)
+	if ok && f < 0 {
+		v.errorf(path, "wartość nie może być ujemna (%g)", f)
+		return f, false
+	}
+	return f, ok
+}
+
+func (v *validator) integer(path string, val any) (int, bool) {
+	f, ok := v.number(path, val)
+	if !ok {
+		return 0, false
+	}
+	if f != math.Trunc(f) || f < 0 {
+		v.errorf(path, "oczekiwano nieujemnej liczby całkowitej (%g)", f)
+		return 0, false
+	}
+	return int(f), true
+}
+
+func (v *validator) str(path string, val any) (string, bool) {
+	s, ok := val.(string)
+	if !ok {
+		v.errorf(path, "oczekiwano tekstu, jest %s", typeName(val))
+	}
+	return s, ok
+}
+
+func (v *validator) boolean(path string, val any) {
+	if _, ok := val.(bool); !ok {
+		v.errorf(path, "oczekiwano true lub false, jest %s", typeName(val))
+	}
+}
+
+func (v *validator) vec2(path string, val any) ([2]float64, bool) {
+	var out [2]float64
+	arr, ok := val.([]any)
+	if !ok || len(arr) != 2 {
+		v.errorf(path, "oczekiwano tablicy [x, y]")
+		return out, false
+	}
+	x, okx := v.number(path+"[0]", arr[0])
+	y, oky := v.number(path+"[1]", arr[1])
+	out = [2]float64{x, y}
+	return out, okx && oky
+}
+
+func (v *validator) array(path string, val any) []any {
+	arr, ok := val.([]any)
+	if !ok {
+		v.errorf(path, "oczekiwano tablicy, jest %s", typeName(val))
+	}
+	return arr
+}
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// bodyInfo - dane ciała potrzebne do sprawdzeń między ciałami
+type bodyInfo struct {
+	name      string
+	pos       [2]float64
+	radius    float64
+	mass      float64
+	hasPos    bool
+	hasRadius bool
+	hasVel    bool
+}
+
+func (v *validator) scene(root any) {
+	var bodies []bodyInfo
+	hasIncludes, hasTemplates := false, false
+	obj := v.object("", root, []field{
+		{"$schema", func(p string, val any) { v.str(p, val) }},
+		{"name", func(p string, val any) { v.str(p, val) }},
+		{"dt", nil},
+		{"bodies", func(p string, val any) { bodies = v.bodies(p, val) }},
+		{"auto_orbit", func(p string, val any) { v.boolean(p, val) }},
+		{"integrator", func(p string, val any) {
+			if s, ok := v.str(p, val); ok && s != "" {
+				if _, ok := physics.Integrators[s]; !ok {
+					v.errorf(p, "nieznana metoda całkowania %q (%s)", s, integratorNames())
+				}
+			}
+		}},
+		{"includes", func(p string, val any) { hasIncludes = len(v.includes(p, val)) > 0 }},
+		{"templates", func(p string, val any) { hasTemplates = v.templates(p, val, bodies, hasIncludes) > 0 }},
+		{"escape", v.escape},
+		{"boundary", v.boundary},
+		{"events", func(p string, val any) {
+			v.object(p, val, []field{
+				{"close_encounter", func(p string, val any) { v.nonNegative(p, val) }},
+			})
+		}},
+		{"units", v.units},
+		{"softening", func(p string, val any) { v.nonNegative(p, val) }},
+	})
+	if obj == nil {
+		return
+	}
+
+	if _, ok := obj["name"]; !ok {
+		v.warnf("name", "brak nazwy sceny")
+	}
+	if val, ok := obj["dt"]; ok {
+		if dt, ok := v.number("dt", val); ok && dt <= 0 && !(dt == 0 && hasIncludes) {
+			v.errorf("dt", "krok czasowy musi być dodatni (%g)", dt)
+		}
+	} else if !hasIncludes {
+		v.errorf("dt", "brak kroku czasowego")
+	}
+	if _, ok := obj["bodies"]; !ok && !hasIncludes && !hasTemplates {
+		v.warnf("bodies", "scena nie ma ciał")
+	}
+
+	if ao, _ := obj["auto_orbit"].(bool); ao && len(bodies) > 0 {
+		c := bodies[0]
+		if c.mass <= 0 {
+			v.errorf("bodies[0].mass", "auto_orbit wymaga dodatniej masy ciała centralnego")
+		}
+		for i, b := range bodies[1:] {
+			if b.hasPos && c.hasPos && b.pos == c.pos && !b.hasVel {
+				v.errorf(fmt.Sprintf("bodies[%d].pos", i+1), "auto_orbit: ciało leży w środku ciała centralnego")
+			}
+		}
+	}
+	v.names(bodies)
+	v.overlaps(bodies)
+}
+
+func integratorNames() string {
+	names := make([]string, 0, len(physics.Integrators))
+	for n := range physics.Integrators {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (v *validator) bodies(path string, val any) []bodyInfo {
+	arr := v.array(path, val)
+	out := make([]bodyInfo, len(arr))
+	for i, b := range arr {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		info := &out[i]
+		obj := v.object(p, b, []field{
+			{"name", func(p string, val any) {
+				if s, ok := v.str(p, val); ok && s != "" {
+					info.name = s
+				}
+			}},
+			{"mass", func(p string, val any) {
+				if m, ok := v.nonNegative(p, val); ok {
+					info.mass = m
+					if m == 0 {
+						v.warnf(p, "masa 0 - ciało nie przyciąga innych")
+					}
+				}
+			}},
+			{"pos", func(p string, val any) { info.pos, info.hasPos = v.vec2(p, val) }},
+			{"vel", func(p string, val any) {
+				vel, ok := v.vec2(p, val)
+				info.hasVel = ok && vel != [2]float64{}
+			}},
+			{"color", func(p string, val any) {
+				if s, ok := v.str(p, val); ok && s != "" && !hexColor.MatchString(s) {
+					v.errorf(p, "niepoprawny kolor %q (oczekiwano #rrggbb)", s)
+				}
+			}},
+			{"radius", func(p string, val any) {
+				if r, ok := v.nonNegative(p, val); ok {
+					info.radius, info.hasRadius = r, true
+					if r == 0 {
+						v.warnf(p, "promień 0 - ciało jest niewidoczne i nie zderza się")
+					}
+				}
+			}},
+			{"locked", func(p string, val any) { v.boolean(p, val) }},
+			{"anti", func(p string, val any) { v.boolean(p, val) }},
+			{"tags", func(p string, val any) {
+				for j, t := range v.array(p, val) {
+					v.str(fmt.Sprintf("%s[%d]", p, j), t)
+				}
+			}},
+		})
+		if obj != nil {
+			if _, ok := obj["mass"]; !ok {
+				v.warnf(p, "brak masy (przyjęto 0)")
+			}
+			if _, ok := obj["pos"]; !ok {
+				v.warnf(p, "brak pozycji (przyjęto [0, 0])")
+			}
+		}
+	}
+	return out
+}
+
+// names zgłasza powtórzone nazwy ciał
+func (v *validator) names(bodies []bodyInfo) {
+	seen := make(map[string]int, len(bodies))
+	for i, b := range bodies {
+		if b.name == "" {
+			continue
+		}
+		if j, ok := seen[b.name]; ok {
+			v.warnf(fmt.Sprintf("bodies[%d].name", i), "nazwa %q powtarza się (bodies[%d]), identyfikatorem ciała będzie #%d", b.name, j, i)
+			continue
+		}
+		seen[b.name] = i
+	}
+}
+
+// overlaps zgłasza ciała, które już na starcie na siebie nachodzą
+func (v *validator) overlaps(bodies []bodyInfo) {
+	for i := range bodies {
+		a := bodies[i]
+		if !a.hasPos {
+			continue
+		}
+		for j := i + 1; j < len(bodies); j++ {
+			b := bodies[j]
+			if !b.hasPos {
+				continue
+			}
+			d := math.Hypot(a.pos[0]-b.pos[0], a.pos[1]-b.pos[1])
+			if d == 0 {
+				v.warnf(fmt.Sprintf("bodies[%d].pos", j), "ta sama pozycja co bodies[%d]", i)
+			} else if d < a.radius+b.radius {
+				v.warnf(fmt.Sprintf("bodies[%d]", j), "nakłada się na bodies[%d] (odległość %g < suma promieni %g)", i, d, a.radius+b.radius)
+			}
+		}
+	}
+}
+
+func (v *validator) includes(path string, val any) []any {
+	arr := v.array(path, val)
+	for i, inc := range arr {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		obj := v.object(p, inc, []field{
+			{"path", func(p string, val any) {
+				if s, ok := v.str(p, val); ok && s == "" {
+					v.errorf(p, "pusta ścieżka")
+				}
+			}},
+			{"offset", func(p string, val any) { v.vec2(p, val) }},
+			{"vel", func(p string, val any) { v.vec2(p, val) }},
+			{"rotation", func(p string, val any) { v.number(p, val) }},
+		})
+		if obj != nil {
+			if _, ok := obj["path"]; !ok {
+				v.errorf(p, "brak pola \"path\"")
+			}
+		}
+	}
+	return arr
+}
+
+// templates sprawdza szablony ciał; center musi być nazwą ciała z tego pliku albo wcześniejszego
+// szablonu (przy includes może też pochodzić z dołączonej sceny). Zwraca liczbę szablonów.
+func (v *validator) templates(path string, val any, bodies []bodyInfo, hasIncludes bool) int {
+	names := make(map[string]bool, len(bodies))
+	for _, b := range bodies {
+		if b.name != "" {
+			names[b.name] = true
+		}
+	}
+	arr := v.array(path, val)
+	for i, t := range arr {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		obj := v.object(p, t, []field{
+			{"ring", func(p string, val any) { v.template(p, val, "ring", names, hasIncludes) }},
+			{"grid", func(p string, val any) { v.template(p, val, "grid", names, hasIncludes) }},
+			{"random", func(p string, val any) { v.template(p, val, "random", names, hasIncludes) }},
+		})
+		if obj != nil && len(obj) != 1 {
+			v.errorf(p, "szablon musi mieć dokładnie jedno z pól ring, grid, random")
+		}
+	}
+	return len(arr)
+}
+
+// template sprawdza jeden szablon rodzaju kind i dopisuje nazwy jego ciał do names
+func (v *validator) template(path string, val any, kind string, names map[string]bool, hasIncludes bool) {
+	count := 0
+	positive := func(p string, val any) {
+		if f, ok := v.number(p, val); ok && f <= 0 {
+			v.errorf(p, "wartość musi być dodatnia (%g)", f)
+		}
+	}
+	// count - iloczyn liczników (rows x cols) nasycany na maxTemplateBodies+1; -1 = błędny licznik
+	counter := func(p string, val any) {
+		if n, ok := v.integer(p, val); ok {
+			if n < 1 || n > maxTemplateBodies {
+				v.errorf(p, "wartość musi być w zakresie 1..%d (jest %d)", maxTemplateBodies, n)
+				count = -1
+				return
+			}
+			if count == 0 {
+				count = 1
+			}
+			if count > 0 {
+				count = min(count*n, maxTemplateBodies+1)
+			}
+		}
+	}
+	fields := []field{
+		{"name", func(p string, val any) { v.str(p, val) }},
+		{"mass", func(p string, val any) {
+			if m, ok := v.nonNegative(p, val); ok && m == 0 {
+				v.warnf(p, "masa 0 - ciała nie przyciągają innych")
+			}
+		}},
+		{"body_radius", func(p string, val any) { v.nonNegative(p, val) }},
+		{"color", func(p string, val any) {
+			if s, ok := v.str(p, val); ok && s != "" && !hexColor.MatchString(s) {
+				v.errorf(p, "niepoprawny kolor %q (oczekiwano #rrggbb)", s)
+			}
+		}},
+		{"locked", func(p string, val any) { v.boolean(p, val) }},
+		{"anti", func(p string, val any) { v.boolean(p, val) }},
+		{"tags", func(p string, val any) {
+			for j, t := range v.array(p, val) {
+				v.str(fmt.Sprintf("%s[%d]", p, j), t)
+			}
+		}},
+		{"center", func(p string, val any) {
+			if s, ok := v.str(p, val); ok && s != "" && !names[s] {
+				if hasIncludes {
+					v.warnf(p, "w tym pliku nie ma ciała %q - musi pochodzić z dołączonej sceny", s)
+				} else {
+					v.errorf(p, "nie znaleziono ciała o nazwie %q", s)
+				}
+			}
+		}},
+		{"offset", func(p string, val any) { v.vec2(p, val) }},
+		{"vel", func(p string, val any) { v.vec2(p, val) }},
+		{"orbit", func(p string, val any) { v.boolean(p, val) }},
+		{"retrograde", func(p string, val any) { v.boolean(p, val) }},
+	}
+	var required []string
+	switch kind {
+	case "ring":
+		fields = append(fields,
+			field{"count", counter},
+			field{"radius", positive},
+			field{"phase", func(p string, val any) { v.number(p, val) }},
+		)
+		required = []string{"count", "radius"}
+	case "grid":
+		fields = append(fields,
+			field{"rows", counter},
+			field{"cols", counter},
+			field{"spacing", positive},
+		)
+		required = []string{"rows", "cols", "spacing"}
+	case "random":
+		fields = append(fields,
+			field{"count", counter},
+			field{"distribution", func(p string, val any) {
+				if s, ok := v.str(p, val); ok && s != "" && s != "uniform" && s != "gaussian" {
+					v.errorf(p, "nieznany rozkład %q (uniform, gaussian)", s)
+				}
+			}},
+			field{"radius", positive},
+			field{"seed", func(p string, val any) { v.integer(p, val) }},
+		)
+		required = []string{"count", "radius"}
+	}
+	obj := v.object(path, val, fields)
+	if obj == nil {
+		return
+	}
+	for _, k := range required {
+		if _, ok := obj[k]; !ok {
+			v.errorf(path, "brak pola %q", k)
+		}
+	}
+	if _, ok := obj["mass"]; !ok {
+		v.warnf(path, "brak masy (przyjęto 0)")
+	}
+	if orbit, _ := obj["orbit"].(bool); orbit {
+		if c, _ := obj["center"].(string); c == "" {
+			v.errorf(joinPath(path, "orbit"), "orbit wymaga ciała centralnego (center)")
+		}
+	}
+	if count > maxTemplateBodies {
+		v.errorf(path, "szablon daje więcej niż %d ciał", maxTemplateBodies)
+		return
+	}
+	if name, _ := obj["name"].(string); name != "" {
+		for i := 1; i <= count; i++ {
+			names[fmt.Sprintf("%s-%d", name, i)] = true
+		}
+	}
+}
+
+func (v *validator) escape(path string, val any) {
+	v.object(path, val, []field{
+		{"radius", func(p string, val any) { v.nonNegative(p, val) }},
+		{"remove", func(p string, val any) { v.boolean(p, val) }},
+		{"check_every", func(p string, val any) { v.integer(p, val) }},
+	})
+}
+
+func (v *validator) boundary(path string, val any) {
+	var cfg BoundaryConfig
+	valid := true
+	obj := v.object(path, val, []field{
+		{"type", func(p string, val any) {
+			s, ok := v.str(p, val)
+			cfg.Type = s
+			valid = valid && ok
+		}},
+		{"min", func(p string, val any) {
+			var ok bool
+			cfg.Min, ok = v.vec2(p, val)
+			valid = valid && ok
+		}},
+		{"max", func(p string, val any) {
+			var ok bool
+			cfg.Max, ok = v.vec2(p, val)
+			valid = valid && ok
+		}},
+		{"images", func(p string, val any) {
+			n, ok := v.integer(p, val)
+			cfg.Images = n
+			valid = valid && ok
+		}},
+	})
+	if obj == nil || !valid {
+		return
+	}
+	for _, k := range []string{"type", "min", "max"} {
+		if _, ok := obj[k]; !ok {
+			v.errorf(path, "brak pola %q", k)
+			return
+		}
+	}
+	if _, err := cfg.toPhysics(); err != nil {
+		v.errorf(path, "%v", err)
+	}
+}
+
+func (v *validator) units(path string, val any) {
+	var cfg UnitsConfig
+	valid := true
+	text := func(dst *string) func(p string, val any) {
+		return func(p string, val any) {
+			s, ok := v.str(p, val)
+			*dst = s
+			valid = valid && ok
+		}
+	}
+	if v.object(path, val, []field{
+		{"system", text(&cfg.System)},
+		{"length", text(&cfg.Length)},
+		{"mass", text(&cfg.Mass)},
+		{"time", text(&cfg.Time)},
+	}) == nil || !valid {
+		return
+	}
+	if _, err := cfg.toUnits(); err != nil {
+		v.errorf(path, "%v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package simulation
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// body - ciało sceny testowej w JSON
+const body = `{"name": "a", "mass": 1, "pos": [0, 0], "radius": 1, "color": "#ffffff"}`
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		scene string
+		want  []Issue // ścieżka, waga i fragment komunikatu
+	}{
+		{"poprawna scena", `{"name": "s", "dt": 0.1, "bodies": [` + body + `]}`, nil},
+		{
+			"błąd składni z pozycją",
+			"{\n  \"name\": \"s\",\n  \"dt\": 0.1,\n}",
+			[]Issue{{"", SeverityError, "w linii 4, kolumnie 1"}},
+		},
+		{
+			"nieznany klucz z podpowiedzią",
+			`{"name": "s", "dt": 0.1, "autoOrbit": true, "bodies": [` + body + `]}`,
+			[]Issue{{"autoOrbit", SeverityError, `nieznany klucz (czy chodziło o "auto_orbit"?)`}},
+		},
+		{
+			"klucz z inną wielkością liter jest sprawdzany jak poprawny",
+			`{"name": "s", "DT": 0.1, "Bodies": [{"name": "a", "Mass": -1, "pos": [0, 0], "radius": 1, "color": "#ffffff"}]}`,
+			[]Issue{
+				{"Bodies", SeverityWarning, `klucz powinien być zapisany jako "bodies"`},
+				{"DT", SeverityWarning, `klucz powinien być zapisany jako "dt"`},
+				{"bodies[0].Mass", SeverityWarning, `klucz powinien być zapisany jako "mass"`},
+				{"bodies[0].mass", SeverityError, "wartość nie może być ujemna"},
+			},
+		},
+		{
+			"typy wartości",
+			`{"name": "s", "dt": "1", "bodies": [{"name": "a", "mass": 1, "pos": [0], "radius": true, "color": "white"}]}`,
+			[]Issue{
+				{"dt", SeverityError, "oczekiwano liczby, jest tekst"},
+				{"bodies[0].pos", SeverityError, ""},
+				{"bodies[0].color", SeverityError, `niepoprawny kolor "white"`},
+				{"bodies[0].radius", SeverityError, "oczekiwano liczby, jest wartość logiczna"},
+			},
+		},
+		{
+			"brak dt, pusta scena",
+			`{"name": "s"}`,
+			[]Issue{{"dt", SeverityError, "brak kroku czasowego"}, {"bodies", SeverityWarning, "scena nie ma ciał"}},
+		},
+		{"dt 0 przy includes", `{"name": "s", "dt": 0, "includes": [{"path": "x.json"}]}`, nil},
+		{"ujemne dt", `{"name": "s", "dt": -1, "bodies": [` + body + `]}`, []Issue{{"dt", SeverityError, "krok czasowy musi być dodatni"}}},
+		{
+			"powtórzona nazwa i nakładające się ciała",
+			`{"name": "s", "dt": 1, "bodies": [` + body + `, {"name": "a", "mass": 1, "pos": [1, 0], "radius": 1, "color": "#ffffff"}]}`,
+			[]Issue{
+				{"bodies[1].name", SeverityWarning, `nazwa "a" powtarza się (bodies[0]), identyfikatorem ciała będzie #1`},
+				{"bodies[1]", SeverityWarning, "nakłada się na bodies[0]"},
+			},
+		},
+		{
+			"ustawienia sceny",
+			`{"name": "s", "dt": 1, "integrator": "rk45", "softening": -1, "units": {"length": "AU", "mass": "Msun"},
+			  "boundary": {"type": "reflect", "min": [10, 10], "max": [0, 0]}, "bodies": [` + body + `]}`,
+			[]Issue{
+				{"integrator", SeverityError, `nieznana metoda całkowania "rk45"`},
+				{"boundary", SeverityError, ""},
+				{"units", SeverityError, "brak jednostki czasu"},
+				{"softening", SeverityError, "wartość nie może być ujemna"},
+			},
+		},
+		{
+			"includes",
+			`{"name": "s", "includes": [{"offset": [1, 2]}, {"path": "", "rotation": "90"}]}`,
+			[]Issue{
+				{"includes[0]", SeverityError, `brak pola "path"`},
+				{"includes[1].path", SeverityError, "pusta ścieżka"},
+				{"includes[1].rotation", SeverityError, "oczekiwano liczby"},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			checkIssues(t, Validate([]byte(tc.scene)), tc.want)
+		})
+	}
+}
+
+// checkIssues sprawdza, że issues to dokładnie want (w dowolnej kolejności); Message w want
+// jest fragmentem komunikatu
+func checkIssues(t *testing.T, issues, want []Issue) {
+	t.Helper()
+	used := make([]bool, len(issues))
+	for _, w := range want {
+		found := false
+		for i, is := range issues {
+			if !used[i] && is.Path == w.Path && is.Severity == w.Severity && strings.Contains(is.Message, w.Message) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("brak problemu %s", w)
+		}
+	}
+	for i, is := range issues {
+		if !used[i] {
+			t.Errorf("nieoczekiwany problem %s", is)
+		}
+	}
+}
+
+// TestValidateFS - problemy w dołączonych scenach mają przedrostek includes[i], a błąd
+// w dołączonym pliku jest błędem całej sceny
+func TestValidateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.json":    {Data: []byte(`{"name": "m", "dt": 1, "includes": [{"path": "sub/a.json"}, {"path": "missing.json"}]}`)},
+		"sub/a.json":   {Data: []byte(`{"name": "a", "dt": 1, "includes": [{"path": "b.json", "offset": [10, 0]}], "bodies": [` + body + `]}`)},
+		"sub/b.json":   {Data: []byte(`{"dt": 1, "bodies": [{"name": "b", "mass": 1, "pos": [0, 0], "radius": 1, "color": "#12345"}]}`)},
+		"broken.json":  {Data: []byte(`{"name": "x", "dt": 1, "bodies": [`)},
+		"include.json": {Data: []byte(`{"name": "i", "includes": [{"path": "broken.json"}]}`)},
+	}
+	issues, err := ValidateFS(fsys, "main.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIssues(t, issues, []Issue{
+		{"includes[0].includes[0].name", SeverityWarning, "brak nazwy sceny"},
+		{"includes[0].includes[0].bodies[0].color", SeverityError, `niepoprawny kolor "#12345"`},
+		{"includes[1].path", SeverityError, "błąd odczytu pliku"},
+	})
+	if !HasErrors(issues) {
+		t.Fatal("HasErrors nie widzi błędów w dołączonych plikach")
+	}
+
+	issues, err = ValidateFS(fsys, "include.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkIssues(t, issues, []Issue{{"includes[0]", SeverityError, "błąd parsowania JSON: unexpected EOF"}})
+}
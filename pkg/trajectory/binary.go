@@ -0,0 +1,339 @@
+package trajectory
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// Format binarny (.gtraj), wszystkie liczby little-endian:
+//
+//	nagłówek:  magic "GSIMTRAJ", wersja uint16, flagi uint16, klatek na blok uint32,
+//	           długość metadanych uint32, metadane (Header jako JSON)
+//	blok:      liczba klatek uint32, długość danych uint32, długość zapisana uint32,
+//	           krok pierwszej klatki int64, czas pierwszej klatki float64, dane
+//	           (po flagFlate skompresowane compress/flate)
+//	indeks:    dla każdego bloku: offset uint64, numer pierwszej klatki uint64,
+//	           liczba klatek uint32, krok int64, czas float64
+//	stopka:    offset indeksu uint64, liczba bloków uint32, magic "GSIMINDX"
+//
+// Dane klatki: krok int64, czas float64, liczba ciał uint32, bajt 1/0 mówiący, czy
+// następuje lista identyfikatorów ciał (długość uvarint + bajty UTF-8 dla każdego ciała;
+// zapisywana w pierwszej klatce bloku i wtedy, gdy zbiór ciał się zmienił - inaczej
+// obowiązuje lista z poprzedniej klatki), potem kolumny x, y, vx, vy, ax, ay, mass
+// (po liczbie ciał wartości w każdej); wersja 1 formatu nie miała identyfikatorów.
+// Przy flagDelta wartości są zapisywane jako XOR bitów z poprzednią klatką bloku
+// (o ile ma tyle samo ciał), co przy flate daje dużo lepszą kompresję. Pierwsza klatka
+// bloku jest zawsze pełna, więc każdy blok da się zdekodować niezależnie.
+// Plik bez stopki (przerwany zapis) da się odczytać, przechodząc kolejno po blokach.
+
+// BinaryVersion - wersja formatu binarnego (czytane są też pliki w wersji 1)
+const BinaryVersion = 2
+
+const (
+	binaryMagic = "GSIMTRAJ"
+	indexMagic  = "GSIMINDX"
+
+	flagFloat32 = 1 << 0
+	flagDelta   = 1 << 1
+	flagFlate   = 1 << 2
+
+	chunkHeaderSize = 28
+	indexEntrySize  = 36
+	trailerSize     = 20
+
+	// DefaultChunkFrames - domyślna liczba klatek w bloku
+	DefaultChunkFrames = 64
+)
+
+// numColumns - liczba kolumn wartości na ciało
+const numColumns = 7
+
+// BinaryOptions - opcje zapisu w formacie binarnym
+type BinaryOptions struct {
+	Float32     bool // zapis wartości jako float32 zamiast float64
+	Delta       bool // kodowanie różnicowe (XOR z poprzednią klatką)
+	Compress    bool // kompresja bloków compress/flate
+	ChunkFrames int  // liczba klatek w bloku (0 = DefaultChunkFrames)
+}
+
+func (o BinaryOptions) flags() uint16 {
+	var f uint16
+	if o.Float32 {
+		f |= flagFloat32
+	}
+	if o.Delta {
+		f |= flagDelta
+	}
+	if o.Compress {
+		f |= flagFlate
+	}
+	return f
+}
+
+// indexEntry - pozycja bloku w pliku
+type indexEntry struct {
+	offset     uint64
+	firstFrame uint64
+	frames     uint32
+	firstStep  int64
+	firstTime  float64
+}
+
+// BinaryWriter zapisuje trajektorię w formacie binarnym
+type BinaryWriter struct {
+	w       *bufio.Writer
+	opts    BinaryOptions
+	offset  uint64
+	header  bool
+	err     error
+	frame   Frame
+	chunk   bytes.Buffer
+	pending indexEntry
+	prev    []uint64 // bity wartości poprzedniej klatki bloku (dla Delta)
+	prevN   int
+	prevIDs []string // identyfikatory ciał ostatnio zapisane w bloku
+	index   []indexEntry
+	frames  uint64
+	zbuf    bytes.Buffer
+	zw      *flate.Writer
+}
+
+// NewBinaryWriter tworzy Writer zapisujący format binarny do w
+func NewBinaryWriter(w io.Writer, opts BinaryOptions) *BinaryWriter {
+	if opts.ChunkFrames <= 0 {
+		opts.ChunkFrames = DefaultChunkFrames
+	}
+	return &BinaryWriter{w: bufio.NewWriter(w), opts: opts, prevN: -1}
+}
+
+func (b *BinaryWriter) write(p []byte) {
+	if b.err != nil {
+		return
+	}
+	_, b.err = b.w.Write(p)
+	b.offset += uint64(len(p))
+}
+
+func (b *BinaryWriter) writeHeader(h Header) {
+	meta, err := json.Marshal(h)
+	if err != nil {
+		b.err = err
+		return
+	}
+	var hdr []byte
+	hdr = append(hdr, binaryMagic...)
+	hdr = binary.LittleEndian.AppendUint16(hdr, BinaryVersion)
+	hdr = binary.LittleEndian.AppendUint16(hdr, b.opts.flags())
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(b.opts.ChunkFrames))
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(len(meta)))
+	hdr = append(hdr, meta...)
+	b.write(hdr)
+	b.header = true
+}
+
+func (b *BinaryWriter) WriteFrame(s *simulation.Simulator) error {
+	FrameOf(s, &b.frame)
+	return b.Write(HeaderOf(s), &b.frame)
+}
+
+func (b *BinaryWriter) Write(h Header, f *Frame) error {
+	if !b.header {
+		b.writeHeader(h)
+	}
+	if b.err != nil {
+		return b.err
+	}
+	if b.pending.frames == 0 {
+		b.pending = indexEntry{firstFrame: b.frames, firstStep: f.Step, firstTime: f.Time}
+		b.prevN = -1
+	}
+	b.encodeFrame(f)
+	b.pending.frames++
+	b.frames++
+	if int(b.pending.frames) >= b.opts.ChunkFrames {
+		b.flushChunk()
+	}
+	return b.err
+}
+
+// encodeFrame dopisuje klatkę do bieżącego bloku
+func (b *BinaryWriter) encodeFrame(f *Frame) {
+	n := len(f.Bodies)
+	var scratch [8]byte
+	binary.LittleEndian.PutUint64(scratch[:], uint64(f.Step))
+	b.chunk.Write(scratch[:8])
+	binary.LittleEndian.PutUint64(scratch[:], math.Float64bits(f.Time))
+	b.chunk.Write(scratch[:8])
+	binary.LittleEndian.PutUint32(scratch[:], uint32(n))
+	b.chunk.Write(scratch[:4])
+	if b.prevN < 0 || !sameIDs(b.prevIDs, f.Bodies) {
+		b.chunk.WriteByte(1)
+		b.prevIDs = b.prevIDs[:0]
+		for i := range f.Bodies {
+			id := f.Bodies[i].ID
+			k := binary.PutUvarint(scratch[:], uint64(len(id)))
+			b.chunk.Write(scratch[:k])
+			b.chunk.WriteString(id)
+			b.prevIDs = append(b.prevIDs, id)
+		}
+	} else {
+		b.chunk.WriteByte(0)
+	}
+
+	delta := b.opts.Delta && b.prevN == n
+	if len(b.prev) < n*numColumns {
+		b.prev = make([]uint64, n*numColumns)
+	}
+	for c := 0; c < numColumns; c++ {
+		for i := range f.Bodies {
+			bits := valueBits(column(&f.Bodies[i], c), b.opts.Float32)
+			k := c*n + i
+			v := bits
+			if delta {
+				v ^= b.prev[k]
+			}
+			b.prev[k] = bits
+			if b.opts.Float32 {
+				binary.LittleEndian.PutUint32(scratch[:], uint32(v))
+				b.chunk.Write(scratch[:4])
+			} else {
+				binary.LittleEndian.PutUint64(scratch[:], v)
+				b.chunk.Write(scratch[:8])
+			}
+		}
+	}
+	b.prevN = n
+}
+
+// flushChunk zapisuje zebrany blok do pliku
+func (b *BinaryWriter) flushChunk() {
+	if b.pending.frames == 0 || b.err != nil {
+		return
+	}
+	data := b.chunk.Bytes()
+	rawLen := len(data)
+	if b.opts.Compress {
+		b.zbuf.Reset()
+		if b.zw == nil {
+			b.zw, _ = flate.NewWriter(&b.zbuf, flate.DefaultCompression)
+		} else {
+			b.zw.Reset(&b.zbuf)
+		}
+		b.zw.Write(data)
+		if err := b.zw.Close(); err != nil {
+			b.err = err
+			return
+		}
+		data = b.zbuf.Bytes()
+	}
+	b.pending.offset = b.offset
+	var hdr []byte
+	hdr = binary.LittleEndian.AppendUint32(hdr, b.pending.frames)
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(rawLen))
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(len(data)))
+	hdr = binary.LittleEndian.AppendUint64(hdr, uint64(b.pending.firstStep))
+	hdr = binary.LittleEndian.AppendUint64(hdr, math.Float64bits(b.pending.firstTime))
+	b.write(hdr)
+	b.write(data)
+	b.index = append(b.index, b.pending)
+	b.pending = indexEntry{}
+	b.chunk.Reset()
+}
+
+// Close zapisuje ostatni blok, indeks i stopkę
+func (b *BinaryWriter) Close() error {
+	if !b.header {
+		b.writeHeader(Header{})
+	}
+	b.flushChunk()
+	indexOffset := b.offset
+	var buf []byte
+	for _, e := range b.index {
+		buf = binary.LittleEndian.AppendUint64(buf, e.offset)
+		buf = binary.LittleEndian.AppendUint64(buf, e.firstFrame)
+		buf = binary.LittleEndian.AppendUint32(buf, e.frames)
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(e.firstStep))
+		buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(e.firstTime))
+	}
+	buf = binary.LittleEndian.AppendUint64(buf, indexOffset)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(b.index)))
+	buf = append(buf, indexMagic...)
+	b.write(buf)
+	if b.err != nil {
+		return b.err
+	}
+	return b.w.Flush()
+}
+
+// sameIDs mówi, czy ciała klatki mają kolejno identyfikatory ids
+func sameIDs(ids []string, bodies []BodyState) bool {
+	if len(ids) != len(bodies) {
+		return false
+	}
+	for i := range bodies {
+		if bodies[i].ID != ids[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// column zwraca wartość kolumny c ciała b (kolejność jak w columns)
+func column(b *BodyState, c int) float64 {
+	switch c {
+	case 0:
+		return b.Pos.X
+	case 1:
+		return b.Pos.Y
+	case 2:
+		return b.Vel.X
+	case 3:
+		return b.Vel.Y
+	case 4:
+		return b.Acc.X
+	case 5:
+		return b.Acc.Y
+	}
+	return b.Mass
+}
+
+// setColumn ustawia wartość kolumny c ciała b
+func setColumn(b *BodyState, c int, v float64) {
+	switch c {
+	case 0:
+		b.Pos.X = v
+	case 1:
+		b.Pos.Y = v
+	case 2:
+		b.Vel.X = v
+	case 3:
+		b.Vel.Y = v
+	case 4:
+		b.Acc.X = v
+	case 5:
+		b.Acc.Y = v
+	default:
+		b.Mass = v
+	}
+}
+
+func valueBits(v float64, f32 bool) uint64 {
+	if f32 {
+		return uint64(math.Float32bits(float32(v)))
+	}
+	return math.Float64bits(v)
+}
+
+func bitsValue(bits uint64, f32 bool) float64 {
+	if f32 {
+		return float64(math.Float32frombits(uint32(bits)))
+	}
+	return math.Float64frombits(bits)
+}
@@ -0,0 +1,183 @@
+package trajectory
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+
+	"gravity-sim/pkg/physics"
+)
+
+// testFrames tworzy n klatek; zbiór ciał zmienia się w trakcie (ciało dodane w klatce 7,
+// usunięte w klatce 15), aby sprawdzić listy identyfikatorów i klatki bez kodowania różnicowego
+func testFrames(n int) []Frame {
+	frames := make([]Frame, n)
+	for i := range frames {
+		ids := []string{"sun", "earth", "moon"}
+		if i >= 7 && i < 15 {
+			ids = append(ids, "comet")
+		}
+		if i >= 15 {
+			ids = []string{"sun", "moon"}
+		}
+		f := &frames[i]
+		f.Step = int64(10 * i)
+		f.Time = 0.1 * float64(i)
+		for k, id := range ids {
+			v := float64(i) + float64(k)/7
+			f.Bodies = append(f.Bodies, BodyState{
+				ID:   id,
+				Pos:  physics.Vec2{X: math.Cos(v) * 100, Y: math.Sin(v) * 100},
+				Vel:  physics.Vec2{X: -math.Sin(v), Y: math.Cos(v)},
+				Acc:  physics.Vec2{X: -math.Cos(v) / 3, Y: -math.Sin(v) / 3},
+				Mass: float64(k + 1),
+			})
+		}
+	}
+	return frames
+}
+
+func writeBinary(t *testing.T, frames []Frame, opts BinaryOptions) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewBinaryWriter(&buf, opts)
+	for i := range frames {
+		if err := w.Write(Header{Scene: "test", Dt: 0.1, G: 1}, &frames[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// checkFrames porównuje pierwsze Len() klatek pliku z want (float32 - z dokładnością float32)
+func checkFrames(t *testing.T, r *Reader, want []Frame, f32 bool) {
+	t.Helper()
+	if r.Len() > len(want) {
+		t.Fatalf("Len() = %d, zapisano %d klatek", r.Len(), len(want))
+	}
+	round := func(v float64) float64 {
+		if f32 {
+			return float64(float32(v))
+		}
+		return v
+	}
+	for i := 0; i < r.Len(); i++ {
+		got, err := r.Frame(i)
+		if err != nil {
+			t.Fatalf("Frame(%d): %v", i, err)
+		}
+		w := &want[i]
+		if got.Step != w.Step || got.Time != w.Time || len(got.Bodies) != len(w.Bodies) {
+			t.Fatalf("klatka %d: krok %d, czas %g, %d ciał; oczekiwano %d, %g, %d",
+				i, got.Step, got.Time, len(got.Bodies), w.Step, w.Time, len(w.Bodies))
+		}
+		for k, b := range got.Bodies {
+			wb := w.Bodies[k]
+			wb.Pos = physics.Vec2{X: round(wb.Pos.X), Y: round(wb.Pos.Y)}
+			wb.Vel = physics.Vec2{X: round(wb.Vel.X), Y: round(wb.Vel.Y)}
+			wb.Acc = physics.Vec2{X: round(wb.Acc.X), Y: round(wb.Acc.Y)}
+			wb.Mass = round(wb.Mass)
+			if b != wb {
+				t.Fatalf("klatka %d, ciało %d: %+v, oczekiwano %+v", i, k, b, wb)
+			}
+		}
+	}
+}
+
+var binaryOptions = []BinaryOptions{
+	{ChunkFrames: 4},
+	{ChunkFrames: 4, Float32: true},
+	{ChunkFrames: 4, Delta: true, Compress: true},
+	{ChunkFrames: 5, Float32: true, Delta: true, Compress: true},
+	{},
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	frames := testFrames(23)
+	for _, opts := range binaryOptions {
+		t.Run(fmt.Sprintf("%+v", opts), func(t *testing.T) {
+			data := writeBinary(t, frames, opts)
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Partial() || r.Len() != len(frames) {
+				t.Fatalf("Len() = %d (partial %v), oczekiwano %d", r.Len(), r.Partial(), len(frames))
+			}
+			if h := r.Header(); h.Scene != "test" || h.Dt != 0.1 {
+				t.Fatalf("nagłówek %+v", h)
+			}
+			checkFrames(t, r, frames, opts.Float32)
+
+			for _, tc := range []struct {
+				step int64
+				want int
+			}{{-1, -1}, {0, 0}, {15, 1}, {100, 10}, {1e9, len(frames) - 1}} {
+				if got, err := r.SearchStep(tc.step); err != nil || got != tc.want {
+					t.Errorf("SearchStep(%d) = %d, %v; oczekiwano %d", tc.step, got, err, tc.want)
+				}
+			}
+		})
+	}
+}
+
+// TestBinaryTruncated - plik ucięty w dowolnym miejscu (bez stopki, z częścią indeksu albo
+// z niepełnym ostatnim blokiem) daje tylko pełne, poprawne klatki
+func TestBinaryTruncated(t *testing.T) {
+	frames := testFrames(23)
+	for _, opts := range binaryOptions {
+		t.Run(fmt.Sprintf("%+v", opts), func(t *testing.T) {
+			data := writeBinary(t, frames, opts)
+			chunkFrames := opts.ChunkFrames
+			if chunkFrames == 0 {
+				chunkFrames = DefaultChunkFrames
+			}
+			chunks := (len(frames) + chunkFrames - 1) / chunkFrames
+			indexStart := len(data) - trailerSize - chunks*indexEntrySize
+
+			for cut := 1; cut < len(data); cut++ {
+				part := data[:len(data)-cut]
+				r, err := NewReader(bytes.NewReader(part), int64(len(part)))
+				if err != nil {
+					// ucięty nagłówek pliku
+					if len(part) >= 20 && len(part) > indexStart {
+						t.Fatalf("ucięte %d bajtów: %v", cut, err)
+					}
+					continue
+				}
+				if !r.Partial() {
+					t.Fatalf("ucięte %d bajtów: plik bez stopki nie jest oznaczony jako Partial", cut)
+				}
+				// bez stopki, ale ze wszystkimi blokami - wszystkie klatki są dostępne
+				if len(part) >= indexStart && r.Len() != len(frames) {
+					t.Fatalf("ucięte %d bajtów (część indeksu): Len() = %d, oczekiwano %d", cut, r.Len(), len(frames))
+				}
+				if r.Len()%chunkFrames != 0 && r.Len() != len(frames) {
+					t.Fatalf("ucięte %d bajtów: Len() = %d nie odpowiada pełnym blokom", cut, r.Len())
+				}
+				checkFrames(t, r, frames, opts.Float32)
+			}
+		})
+	}
+}
+
+// TestBinaryCorrupt - uszkodzone długości w pliku dają błąd zamiast ogromnej alokacji lub paniki
+func TestBinaryCorrupt(t *testing.T) {
+	data := writeBinary(t, testFrames(23), BinaryOptions{ChunkFrames: 4, Delta: true, Compress: true})
+	for pos := 0; pos+4 <= len(data); pos++ {
+		bad := bytes.Clone(data)
+		copy(bad[pos:], []byte{0xf0, 0xff, 0xff, 0xff})
+		r, err := NewReader(bytes.NewReader(bad), int64(len(bad)))
+		if err != nil {
+			continue
+		}
+		// uszkodzony nagłówek może zapowiadać miliardy klatek - wystarczą pierwsze i ostatnie
+		for _, i := range []int{0, 1, r.Len() / 2, r.Len() - 1} {
+			r.Frame(i)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package trajectory
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// CSVWriter zapisuje trajektorię jako CSV: jeden wiersz na ciało na klatkę.
+// Plik zaczyna się od nagłówka w liniach komentarza "#" (pandas: comment="#",
+// R: comment.char="#"), po którym następuje wiersz z nazwami kolumn.
+type CSVWriter struct {
+	w      *bufio.Writer
+	header bool
+	frame  Frame
+	buf    []byte
+}
+
+// NewCSVWriter tworzy Writer zapisujący CSV do w
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: bufio.NewWriter(w)}
+}
+
+func (c *CSVWriter) writeHeader(h Header) {
+	c.w.WriteString("# gravity-sim trajectory, format csv, version " + strconv.Itoa(Version) + "\n")
+	c.w.WriteString("# scene: " + h.Scene + "\n")
+	c.w.WriteString("# dt: " + strconv.FormatFloat(h.Dt, 'g', -1, 64) + "\n")
+	c.w.WriteString("# units: " + unitsNote(h) + "\n")
+	c.w.WriteString("# columns:\n")
+	for _, col := range columns {
+		c.w.WriteString("#   " + col.name + " - " + col.doc + "\n")
+	}
+	for i, col := range columns {
+		if i > 0 {
+			c.w.WriteByte(',')
+		}
+		c.w.WriteString(col.name)
+	}
+	c.w.WriteByte('\n')
+	c.header = true
+}
+
+func (c *CSVWriter) WriteFrame(s *simulation.Simulator) error {
+	FrameOf(s, &c.frame)
+	return c.Write(HeaderOf(s), &c.frame)
+}
+
+func (c *CSVWriter) Write(h Header, f *Frame) error {
+	if !c.header {
+		c.writeHeader(h)
+	}
+	for i, b := range f.Bodies {
+		buf := c.buf[:0]
+		buf = strconv.AppendFloat(buf, f.Time, 'g', -1, 64)
+		buf = append(buf, ',')
+		buf = strconv.AppendInt(buf, f.Step, 10)
+		buf = append(buf, ',')
+		buf = appendField(buf, bodyID(f, i))
+		for _, v := range [...]float64{b.Pos.X, b.Pos.Y, b.Vel.X, b.Vel.Y, b.Acc.X, b.Acc.Y, b.Mass} {
+			buf = append(buf, ',')
+			buf = strconv.AppendFloat(buf, v, 'g', -1, 64)
+		}
+		buf = append(buf, '\n')
+		if _, err := c.w.Write(buf); err != nil {
+			return err
+		}
+		c.buf = buf
+	}
+	return nil
+}
+
+func (c *CSVWriter) Close() error {
+	return c.w.Flush()
+}
+
+// appendField dopisuje pole tekstowe CSV, ujmując je w cudzysłów, gdy zawiera znaki specjalne (RFC 4180)
+func appendField(buf []byte, s string) []byte {
+	if !strings.ContainsAny(s, ",\"\r\n") && strings.TrimSpace(s) == s {
+		return append(buf, s...)
+	}
+	buf = append(buf, '"')
+	buf = append(buf, strings.ReplaceAll(s, `"`, `""`)...)
+	return append(buf, '"')
+}
@@ -0,0 +1,99 @@
+package trajectory
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"gravity-sim/pkg/simulation"
+)
+
+// NDJSONWriter zapisuje trajektorię jako JSON rozdzielany znakami nowej linii.
+// Pierwsza linia to rekord nagłówka ("type": "header") z opisem kolumn i jednostek,
+// kolejne to rekordy "type": "state", po jednym na ciało na klatkę
+// (pandas: pd.read_json(path, lines=True).query("type == 'state'")).
+type NDJSONWriter struct {
+	w      *bufio.Writer
+	enc    *json.Encoder
+	header bool
+	frame  Frame
+}
+
+type ndjsonHeader struct {
+	Type    string            `json:"type"`
+	Format  string            `json:"format"`
+	Version int               `json:"version"`
+	Scene   string            `json:"scene"`
+	Dt      float64           `json:"dt"`
+	Units   string            `json:"units"`
+	Columns map[string]string `json:"columns"`
+}
+
+type ndjsonState struct {
+	Type string  `json:"type"`
+	Time float64 `json:"time"`
+	Step int64   `json:"step"`
+	Body string  `json:"body"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	VX   float64 `json:"vx"`
+	VY   float64 `json:"vy"`
+	AX   float64 `json:"ax"`
+	AY   float64 `json:"ay"`
+	Mass float64 `json:"mass"`
+}
+
+// NewNDJSONWriter tworzy Writer zapisujący NDJSON do w
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	bw := bufio.NewWriter(w)
+	return &NDJSONWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (n *NDJSONWriter) WriteFrame(s *simulation.Simulator) error {
+	FrameOf(s, &n.frame)
+	return n.Write(HeaderOf(s), &n.frame)
+}
+
+func (n *NDJSONWriter) Write(h Header, f *Frame) error {
+	if !n.header {
+		h := ndjsonHeader{
+			Type:    "header",
+			Format:  "gravity-sim-trajectory",
+			Version: Version,
+			Scene:   h.Scene,
+			Dt:      h.Dt,
+			Units:   unitsNote(h),
+			Columns: make(map[string]string, len(columns)),
+		}
+		for _, col := range columns {
+			h.Columns[col.name] = col.doc
+		}
+		if err := n.enc.Encode(h); err != nil {
+			return err
+		}
+		n.header = true
+	}
+	for i, b := range f.Bodies {
+		rec := ndjsonState{
+			Type: "state",
+			Time: f.Time,
+			Step: f.Step,
+			Body: bodyID(f, i),
+			X:    b.Pos.X,
+			Y:    b.Pos.Y,
+			VX:   b.Vel.X,
+			VY:   b.Vel.Y,
+			AX:   b.Acc.X,
+			AY:   b.Acc.Y,
+			Mass: b.Mass,
+		}
+		if err := n.enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *NDJSONWriter) Close() error {
+	return n.w.Flush()
+}
@@ -0,0 +1,388 @@
+package trajectory
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// Reader odczytuje plik trajektorii w formacie binarnym z dostępem do dowolnej klatki
+type Reader struct {
+	r      io.ReaderAt
+	size   int64
+	closer io.Closer
+
+	header      Header
+	version     uint16
+	flags       uint16
+	chunkFrames uint32 // największa liczba klatek w bloku (z nagłówka pliku)
+	index       []indexEntry
+	frames      int
+	partial     bool
+
+	// ostatnio zdekodowany blok
+	cached int
+	chunk  []Frame
+}
+
+// Open otwiera plik trajektorii w formacie binarnym
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r, err := NewReader(f, st.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.closer = f
+	return r, nil
+}
+
+// NewReader tworzy Reader dla danych r o rozmiarze size
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	rd := &Reader{r: r, size: size, cached: -1}
+	start, err := rd.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	if err := rd.readIndex(); err != nil {
+		// brak stopki (przerwany zapis) - odtwórz indeks z nagłówków bloków
+		if err := rd.scanChunks(start); err != nil {
+			return nil, err
+		}
+		rd.partial = true
+	}
+	for _, e := range rd.index {
+		rd.frames += int(e.frames)
+	}
+	return rd, nil
+}
+
+func (rd *Reader) readHeader() (int64, error) {
+	var hdr [20]byte
+	if _, err := rd.r.ReadAt(hdr[:], 0); err != nil {
+		return 0, fmt.Errorf("błąd odczytu nagłówka trajektorii: %v", err)
+	}
+	if string(hdr[:8]) != binaryMagic {
+		return 0, errors.New("to nie jest plik trajektorii gravity-sim")
+	}
+	rd.version = binary.LittleEndian.Uint16(hdr[8:])
+	if rd.version < 1 || rd.version > BinaryVersion {
+		return 0, fmt.Errorf("nieobsługiwana wersja trajektorii %d (obsługiwane 1..%d)", rd.version, BinaryVersion)
+	}
+	rd.flags = binary.LittleEndian.Uint16(hdr[10:])
+	rd.chunkFrames = binary.LittleEndian.Uint32(hdr[12:])
+	if rd.chunkFrames == 0 {
+		return 0, errors.New("uszkodzony nagłówek trajektorii")
+	}
+	metaLen := int64(binary.LittleEndian.Uint32(hdr[16:]))
+	if 20+metaLen > rd.size {
+		return 0, errors.New("uszkodzony nagłówek trajektorii")
+	}
+	meta := make([]byte, metaLen)
+	if _, err := rd.r.ReadAt(meta, 20); err != nil {
+		return 0, fmt.Errorf("błąd odczytu nagłówka trajektorii: %v", err)
+	}
+	if err := json.Unmarshal(meta, &rd.header); err != nil {
+		return 0, fmt.Errorf("błąd nagłówka trajektorii: %v", err)
+	}
+	return 20 + metaLen, nil
+}
+
+func (rd *Reader) readIndex() error {
+	if rd.size < trailerSize {
+		return errors.New("brak stopki")
+	}
+	var tr [trailerSize]byte
+	if _, err := rd.r.ReadAt(tr[:], rd.size-trailerSize); err != nil {
+		return err
+	}
+	if string(tr[12:]) != indexMagic {
+		return errors.New("brak stopki")
+	}
+	// offset jest sprawdzany przed mnożeniem, więc uszkodzona stopka nie wymusi dużej alokacji
+	offset := binary.LittleEndian.Uint64(tr[0:])
+	count := int64(binary.LittleEndian.Uint32(tr[8:]))
+	if offset > uint64(rd.size-trailerSize) || int64(offset)+count*indexEntrySize != rd.size-trailerSize {
+		return errors.New("uszkodzony indeks")
+	}
+	buf := make([]byte, count*indexEntrySize)
+	if _, err := rd.r.ReadAt(buf, int64(offset)); err != nil {
+		return err
+	}
+	rd.index = make([]indexEntry, count)
+	var first uint64
+	for i := range rd.index {
+		p := buf[i*indexEntrySize:]
+		rd.index[i] = indexEntry{
+			offset:     binary.LittleEndian.Uint64(p[0:]),
+			firstFrame: binary.LittleEndian.Uint64(p[8:]),
+			frames:     binary.LittleEndian.Uint32(p[16:]),
+			firstStep:  int64(binary.LittleEndian.Uint64(p[20:])),
+			firstTime:  math.Float64frombits(binary.LittleEndian.Uint64(p[28:])),
+		}
+		// Frame wybiera klatkę w bloku po firstFrame, więc numery muszą być kolejne
+		if e := rd.index[i]; e.firstFrame != first || e.frames == 0 || e.frames > rd.chunkFrames {
+			return errors.New("uszkodzony indeks")
+		}
+		first += uint64(rd.index[i].frames)
+	}
+	return nil
+}
+
+// scanChunks przechodzi po kolejnych blokach od offsetu start i kończy na pierwszym, którego nie
+// da się zdekodować: niepełnym ostatnim bloku albo pozostałościach indeksu bez stopki (plik
+// ucięty w trakcie zapisu indeksu). Każdy blok jest dekodowany, bo sam nagłówek bloku nie
+// odróżnia danych od innych bajtów.
+func (rd *Reader) scanChunks(start int64) error {
+	rd.index = nil
+	var first uint64
+	var hdr [chunkHeaderSize]byte
+	for off := start; off+chunkHeaderSize <= rd.size; {
+		if _, err := rd.r.ReadAt(hdr[:], off); err != nil {
+			return err
+		}
+		e := indexEntry{
+			offset:     uint64(off),
+			firstFrame: first,
+			frames:     binary.LittleEndian.Uint32(hdr[0:]),
+			firstStep:  int64(binary.LittleEndian.Uint64(hdr[12:])),
+			firstTime:  math.Float64frombits(binary.LittleEndian.Uint64(hdr[20:])),
+		}
+		if _, err := rd.readChunk(e); err != nil {
+			break
+		}
+		rd.index = append(rd.index, e)
+		first += uint64(e.frames)
+		off += chunkHeaderSize + int64(binary.LittleEndian.Uint32(hdr[8:]))
+	}
+	return nil
+}
+
+// Header zwraca opis trajektorii
+func (rd *Reader) Header() Header { return rd.header }
+
+// Len zwraca liczbę klatek w pliku
+func (rd *Reader) Len() int { return rd.frames }
+
+// Partial mówi, czy plik nie miał indeksu (zapis został przerwany)
+func (rd *Reader) Partial() bool { return rd.partial }
+
+// Frame zwraca klatkę o numerze i (0 <= i < Len()); wynik nie może być modyfikowany
+func (rd *Reader) Frame(i int) (*Frame, error) {
+	if i < 0 || i >= rd.frames {
+		return nil, fmt.Errorf("klatka %d poza zakresem [0, %d)", i, rd.frames)
+	}
+	c := sort.Search(len(rd.index), func(k int) bool {
+		return rd.index[k].firstFrame > uint64(i)
+	}) - 1
+	if err := rd.loadChunk(c); err != nil {
+		return nil, err
+	}
+	return &rd.chunk[uint64(i)-rd.index[c].firstFrame], nil
+}
+
+// SearchStep zwraca numer ostatniej klatki o kroku <= step (-1, jeśli takiej nie ma)
+func (rd *Reader) SearchStep(step int64) (int, error) {
+	c := sort.Search(len(rd.index), func(k int) bool {
+		return rd.index[k].firstStep > step
+	}) - 1
+	if c < 0 {
+		return -1, nil
+	}
+	if err := rd.loadChunk(c); err != nil {
+		return -1, err
+	}
+	k := sort.Search(len(rd.chunk), func(k int) bool {
+		return rd.chunk[k].Step > step
+	}) - 1
+	return int(rd.index[c].firstFrame) + k, nil
+}
+
+// loadChunk dekoduje blok c (jeśli nie jest już w pamięci)
+func (rd *Reader) loadChunk(c int) error {
+	if rd.cached == c {
+		return nil
+	}
+	frames, err := rd.readChunk(rd.index[c])
+	if err != nil {
+		return fmt.Errorf("blok %d: %v", c, err)
+	}
+	rd.chunk = frames
+	rd.cached = c
+	return nil
+}
+
+// minFrameSize - najmniejszy rozmiar danych klatki (krok, czas, liczba ciał)
+const minFrameSize = 20
+
+// readChunk odczytuje i dekoduje blok e. Długości z pliku są sprawdzane z rozmiarem pliku
+// i ilością danych, zanim posłużą do alokacji - uszkodzony plik daje błąd, a nie próbę zajęcia
+// gigabajtów pamięci. Nagłówek bloku musi zgadzać się z e (liczba klatek, pierwszy krok).
+func (rd *Reader) readChunk(e indexEntry) ([]Frame, error) {
+	if rd.size < chunkHeaderSize || e.offset > uint64(rd.size-chunkHeaderSize) {
+		return nil, fmt.Errorf("offset %d poza plikiem", e.offset)
+	}
+	off := int64(e.offset)
+	var hdr [chunkHeaderSize]byte
+	if _, err := rd.r.ReadAt(hdr[:], off); err != nil {
+		return nil, fmt.Errorf("błąd odczytu: %v", err)
+	}
+	frames := binary.LittleEndian.Uint32(hdr[0:])
+	if frames != e.frames || frames == 0 || frames > rd.chunkFrames {
+		return nil, fmt.Errorf("niepoprawna liczba klatek %d", frames)
+	}
+	if step := int64(binary.LittleEndian.Uint64(hdr[12:])); step != e.firstStep {
+		return nil, fmt.Errorf("krok pierwszej klatki %d niezgodny z indeksem (%d)", step, e.firstStep)
+	}
+	rawLen := int64(binary.LittleEndian.Uint32(hdr[4:]))
+	storedLen := int64(binary.LittleEndian.Uint32(hdr[8:]))
+	compressed := rd.flags&flagFlate != 0
+	if rawLen < int64(frames)*minFrameSize || (!compressed && storedLen != rawLen) {
+		return nil, fmt.Errorf("niespójne długości danych (%d, zapisano %d)", rawLen, storedLen)
+	}
+	if storedLen > rd.size-off-chunkHeaderSize {
+		return nil, fmt.Errorf("długość danych %d poza plikiem", storedLen)
+	}
+	stored := make([]byte, storedLen)
+	if _, err := rd.r.ReadAt(stored, off+chunkHeaderSize); err != nil {
+		return nil, fmt.Errorf("błąd odczytu: %v", err)
+	}
+	data := stored
+	if compressed {
+		// bufor rośnie razem z rozpakowanymi danymi, zamiast być alokowany z góry na rawLen
+		zr := flate.NewReader(bytes.NewReader(stored))
+		var err error
+		data, err = io.ReadAll(io.LimitReader(zr, rawLen))
+		zr.Close()
+		if err != nil {
+			return nil, fmt.Errorf("błąd dekompresji: %v", err)
+		}
+		if int64(len(data)) != rawLen {
+			return nil, fmt.Errorf("błąd dekompresji: %v", io.ErrUnexpectedEOF)
+		}
+	}
+	decoded, err := rd.decodeChunk(data, int(frames))
+	if err != nil {
+		return nil, err
+	}
+	if decoded[0].Step != e.firstStep {
+		return nil, errors.New("krok pierwszej klatki niezgodny z nagłówkiem bloku")
+	}
+	return decoded, nil
+}
+
+func (rd *Reader) decodeChunk(data []byte, count int) ([]Frame, error) {
+	f32 := rd.flags&flagFloat32 != 0
+	delta := rd.flags&flagDelta != 0
+	width := 8
+	if f32 {
+		width = 4
+	}
+	frames := make([]Frame, count)
+	var prev []uint64
+	var ids []string
+	prevN := -1
+	for fi := range frames {
+		if len(data) < 20 {
+			return nil, errors.New("uszkodzone dane klatki")
+		}
+		f := &frames[fi]
+		f.Step = int64(binary.LittleEndian.Uint64(data[0:]))
+		f.Time = math.Float64frombits(binary.LittleEndian.Uint64(data[8:]))
+		n := int(binary.LittleEndian.Uint32(data[16:]))
+		data = data[20:]
+		if rd.version >= 2 {
+			var err error
+			if data, ids, err = decodeIDs(data, n, ids); err != nil {
+				return nil, err
+			}
+		}
+		if len(data) < n*numColumns*width {
+			return nil, errors.New("uszkodzone dane klatki")
+		}
+		useDelta := delta && prevN == n
+		if len(prev) < n*numColumns {
+			prev = make([]uint64, n*numColumns)
+		}
+		f.Bodies = make([]BodyState, n)
+		if len(ids) == n {
+			for i := range f.Bodies {
+				f.Bodies[i].ID = ids[i]
+			}
+		}
+		for c := 0; c < numColumns; c++ {
+			for i := 0; i < n; i++ {
+				var v uint64
+				if f32 {
+					v = uint64(binary.LittleEndian.Uint32(data))
+				} else {
+					v = binary.LittleEndian.Uint64(data)
+				}
+				data = data[width:]
+				k := c*n + i
+				if useDelta {
+					v ^= prev[k]
+				}
+				prev[k] = v
+				setColumn(&f.Bodies[i], c, bitsValue(v, f32))
+			}
+		}
+		prevN = n
+	}
+	if len(data) != 0 {
+		return nil, errors.New("nadmiarowe dane w bloku")
+	}
+	return frames, nil
+}
+
+// decodeIDs odczytuje (opcjonalną) listę identyfikatorów n ciał; bez listy zwraca prev
+func decodeIDs(data []byte, n int, prev []string) ([]byte, []string, error) {
+	if len(data) < 1 {
+		return nil, nil, errors.New("uszkodzone dane klatki")
+	}
+	present := data[0] != 0
+	data = data[1:]
+	if !present {
+		if len(prev) != n {
+			return nil, nil, errors.New("brak identyfikatorów ciał w klatce")
+		}
+		return data, prev, nil
+	}
+	// każdy identyfikator zajmuje co najmniej bajt długości
+	if n > len(data) {
+		return nil, nil, errors.New("uszkodzone identyfikatory ciał")
+	}
+	ids := make([]string, n)
+	for i := range ids {
+		l, k := binary.Uvarint(data)
+		if k <= 0 || uint64(len(data)-k) < l {
+			return nil, nil, errors.New("uszkodzone identyfikatory ciał")
+		}
+		ids[i] = string(data[k : k+int(l)])
+		data = data[k+int(l):]
+	}
+	return data, ids, nil
+}
+
+// Close zamyka plik otwarty przez Open
+func (rd *Reader) Close() error {
+	if rd.closer != nil {
+		return rd.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package trajectory zapisuje przebieg symulacji (czas, ciało, pozycja, prędkość,
+// przyspieszenie, masa) do plików CSV, NDJSON lub zwartego formatu binarnego
+// i odczytuje pliki binarne z dostępem do dowolnej klatki.
+package trajectory
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gravity-sim/pkg/physics"
+	"gravity-sim/pkg/simulation"
+)
+
+// Version - wersja układu kolumn zapisywanych plików (2: kolumna body zawiera Body.ID zamiast indeksu)
+const Version = 2
+
+// Writer zapisuje kolejne klatki trajektorii
+type Writer interface {
+	// WriteFrame zapisuje bieżący stan wszystkich ciał
+	WriteFrame(s *simulation.Simulator) error
+	// Close opróżnia bufory i zamyka plik (jeśli Writer go otworzył)
+	Close() error
+}
+
+// FrameWriter zapisuje klatki niezależnie od symulatora (np. przy konwersji plików)
+type FrameWriter interface {
+	Writer
+	// Write zapisuje klatkę f; nagłówek h jest używany przy pierwszym wywołaniu
+	Write(h Header, f *Frame) error
+}
+
+// Header - opis trajektorii zapisywany na początku pliku
+type Header struct {
+	Scene string  `json:"scene"`
+	Dt    float64 `json:"dt"`
+	G     float64 `json:"g"`
+	Units string  `json:"units,omitempty"` // układ jednostek, np. "AU / Msun / yr" (puste = umowne)
+}
+
+// BodyState - stan jednego ciała w klatce
+type BodyState struct {
+	ID   string // identyfikator ciała (pusty w plikach binarnych w wersji 1)
+	Pos  physics.Vec2
+	Vel  physics.Vec2
+	Acc  physics.Vec2
+	Mass float64
+}
+
+// Frame - stan wszystkich ciał w jednym kroku
+type Frame struct {
+	Step   int64
+	Time   float64
+	Bodies []BodyState
+}
+
+// HeaderOf zwraca nagłówek trajektorii symulatora s
+func HeaderOf(s *simulation.Simulator) Header {
+	h := Header{Scene: s.Name, Dt: s.Dt, G: s.G}
+	if !s.Units.IsZero() {
+		h.Units = s.Units.String()
+	}
+	return h
+}
+
+// FrameOf zapisuje bieżący stan s do f (bufor f.Bodies jest używany ponownie)
+func FrameOf(s *simulation.Simulator, f *Frame) {
+	f.Step = s.Step
+	f.Time = s.Time
+	f.Bodies = f.Bodies[:0]
+	for _, b := range s.Bodies {
+		f.Bodies = append(f.Bodies, BodyState{ID: b.ID, Pos: b.Pos, Vel: b.Vel, Acc: b.Acc, Mass: b.Mass})
+	}
+}
+
+// Format - format pliku trajektorii
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+	Binary Format = "gtraj"
+)
+
+// ParseFormat zamienia nazwę formatu (lub rozszerzenie pliku) na Format
+func ParseFormat(name string) (Format, error) {
+	switch strings.TrimPrefix(strings.ToLower(name), ".") {
+	case "csv":
+		return CSV, nil
+	case "ndjson", "jsonl":
+		return NDJSON, nil
+	case "gtraj", "bin", "binary":
+		return Binary, nil
+	}
+	return "", fmt.Errorf("nieznany format trajektorii %q (csv, ndjson, gtraj)", name)
+}
+
+// columns - opis kolumn umieszczany w nagłówku pliku
+var columns = []struct{ name, doc string }{
+	{"time", "czas symulacji [time]"},
+	{"step", "numer kroku"},
+	{"body", "identyfikator ciała (nazwa ze sceny albo #n), stały przez cały przebieg"},
+	{"x", "pozycja X [length]"},
+	{"y", "pozycja Y [length]"},
+	{"vx", "prędkość X [length/time]"},
+	{"vy", "prędkość Y [length/time]"},
+	{"ax", "przyspieszenie X [length/time^2]"},
+	{"ay", "przyspieszenie Y [length/time^2]"},
+	{"mass", "masa [mass]"},
+}
+
+// bodyID zwraca identyfikator i-tego ciała klatki; dla plików bez identyfikatorów - indeks
+func bodyID(f *Frame, i int) string {
+	if id := f.Bodies[i].ID; id != "" {
+		return id
+	}
+	return strconv.Itoa(i)
+}
+
+// unitsNote opisuje jednostki zapisanych wartości
+func unitsNote(h Header) string {
+	if h.Units != "" {
+		return "length / mass / time = " + h.Units + ", G = " + fmt.Sprint(h.G)
+	}
+	return "jednostki umowne sceny (length, mass, time jak w pliku sceny), G = " + fmt.Sprint(h.G)
+}
+
+// New tworzy Writer danego formatu piszący do w (format binarny z domyślnymi opcjami)
+func New(w io.Writer, f Format) (FrameWriter, error) {
+	switch f {
+	case CSV:
+		return NewCSVWriter(w), nil
+	case NDJSON:
+		return NewNDJSONWriter(w), nil
+	case Binary:
+		return NewBinaryWriter(w, BinaryOptions{}), nil
+	}
+	return nil, fmt.Errorf("nieznany format trajektorii %q", f)
+}
+
+// Create tworzy plik trajektorii; format jest brany z rozszerzenia, gdy f jest puste
+func Create(path string, f Format) (FrameWriter, error) {
+	if f == "" {
+		var err error
+		if f, err = ParseFormat(filepath.Ext(path)); err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := New(file, f)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &fileWriter{FrameWriter: w, file: file}, nil
+}
+
+// CreateBinary tworzy plik trajektorii w formacie binarnym z podanymi opcjami
+func CreateBinary(path string, opts BinaryOptions) (FrameWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &fileWriter{FrameWriter: NewBinaryWriter(file, opts), file: file}, nil
+}
+
+// fileWriter zamyka plik po zamknięciu Writera
+type fileWriter struct {
+	FrameWriter
+	file *os.File
+}
+
+func (w *fileWriter) Close() error {
+	err := w.FrameWriter.Close()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Recorder zapisuje co Every kroków (Every <= 1 - każdy krok). Jest obserwatorem symulacji
+// (simulation.Observer): po dołączeniu zapisuje stan początkowy, a po odłączeniu zamyka W.
+type Recorder struct {
+	W     Writer
+	Every int
+}
+
+// Record zapisuje klatkę, jeśli bieżący krok przypada na odstęp Every
+func (r *Recorder) Record(s *simulation.Simulator) error {
+	if r.Every > 1 && s.Step%int64(r.Every) != 0 {
+		return nil
+	}
+	return r.W.WriteFrame(s)
+}
+
+func (r *Recorder) OnStart(s *simulation.Simulator) error {
+	return r.W.WriteFrame(s)
+}
+
+func (r *Recorder) OnStep(s *simulation.Simulator, _ float64) error {
+	return r.Record(s)
+}
+
+func (r *Recorder) OnStop(*simulation.Simulator) error {
+	return r.W.Close()
+}
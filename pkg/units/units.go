@@ -0,0 +1,229 @@
+// Package units opisuje układy jednostek scen (np. AU / M☉ / rok, km / kg / s,
+// jednostki N-ciałowe Hénona), wyprowadza z nich stałą grawitacji i przelicza
+// wartości między układami.
+package units
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GSI - stała grawitacji w SI [m^3 kg^-1 s^-2] (CODATA 2018)
+const GSI = 6.67430e-11
+
+// Jednostki długości [m]
+var lengths = map[string]float64{
+	"m":      1,
+	"km":     1e3,
+	"au":     1.495978707e11,
+	"ly":     9.4607304725808e15,
+	"pc":     3.0856775814913673e16,
+	"kpc":    3.0856775814913673e19,
+	"rsun":   6.957e8,
+	"rearth": 6.3781e6,
+}
+
+// Jednostki masy [kg]; masa Słońca wynika z GM☉ (DE440) i GSI
+var masses = map[string]float64{
+	"kg":     1,
+	"g":      1e-3,
+	"msun":   1.3271244004193938e20 / GSI,
+	"mearth": 3.98600435436e14 / GSI,
+	"mjup":   1.26686531900e17 / GSI,
+}
+
+// Jednostki czasu [s]; rok juliański
+var times = map[string]float64{
+	"s":   1,
+	"min": 60,
+	"h":   3600,
+	"day": 86400,
+	"d":   86400,
+	"yr":  3.15576e7,
+	"kyr": 3.15576e10,
+	"myr": 3.15576e13,
+	"gyr": 3.15576e16,
+}
+
+// Unit - jednostka: nazwa i wartość w SI
+type Unit struct {
+	Name string
+	SI   float64
+}
+
+func (u Unit) String() string { return u.Name }
+
+// System - układ jednostek sceny. Zerowa wartość oznacza jednostki umowne
+// (bez bloku units), dla których o G decyduje symulator.
+type System struct {
+	Length, Mass, Time Unit
+	Henon              bool // jednostki N-ciałowe Hénona: G = 1, bez odpowiednika w SI
+}
+
+// Henon zwraca układ jednostek N-ciałowych Hénona (G = M = 1, E = -1/4)
+func Henon() System {
+	return System{
+		Length: Unit{Name: "L_H"},
+		Mass:   Unit{Name: "M_H"},
+		Time:   Unit{Name: "T_H"},
+		Henon:  true,
+	}
+}
+
+// New tworzy układ z nazw jednostek, np. New("AU", "Msun", "yr") lub New("0.01 AU", "Msun", "day")
+func New(length, mass, time string) (System, error) {
+	var s System
+	var err error
+	if s.Length, err = parseUnit(length, lengths, "długości"); err != nil {
+		return s, err
+	}
+	if s.Mass, err = parseUnit(mass, masses, "masy"); err != nil {
+		return s, err
+	}
+	if s.Time, err = parseUnit(time, times, "czasu"); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// parseUnit rozpoznaje "<jednostka>" albo "<liczba> <jednostka>"
+func parseUnit(text string, table map[string]float64, what string) (Unit, error) {
+	name := strings.TrimSpace(text)
+	if name == "" {
+		return Unit{}, fmt.Errorf("brak jednostki %s", what)
+	}
+	factor := 1.0
+	if f := strings.Fields(name); len(f) == 2 {
+		v, err := strconv.ParseFloat(f[0], 64)
+		if err != nil || v <= 0 || math.IsInf(v, 0) {
+			return Unit{}, fmt.Errorf("niepoprawna jednostka %s %q", what, text)
+		}
+		factor = v
+		name = f[1]
+	}
+	si, ok := table[strings.ToLower(name)]
+	if !ok {
+		return Unit{}, fmt.Errorf("nieznana jednostka %s %q (dostępne: %s)", what, name, strings.Join(Names(table), ", "))
+	}
+	return Unit{Name: strings.TrimSpace(text), SI: factor * si}, nil
+}
+
+// Names zwraca posortowane nazwy jednostek z tabeli
+func Names(table map[string]float64) []string {
+	names := make([]string, 0, len(table))
+	for n := range table {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsZero mówi, czy układ to jednostki umowne (brak bloku units)
+func (s System) IsZero() bool {
+	return !s.Henon && s.Length.SI == 0
+}
+
+// G zwraca stałą grawitacji w jednostkach układu (0 dla jednostek umownych)
+func (s System) G() float64 {
+	switch {
+	case s.Henon:
+		return 1
+	case s.IsZero():
+		return 0
+	}
+	return GSI * s.Mass.SI * s.Time.SI * s.Time.SI / (s.Length.SI * s.Length.SI * s.Length.SI)
+}
+
+// Dim - wymiar wielkości jako potęgi długości, masy i czasu
+type Dim struct{ L, M, T int }
+
+// Wymiary używane w symulacji
+var (
+	Length       = Dim{L: 1}
+	Mass         = Dim{M: 1}
+	Time         = Dim{T: 1}
+	Velocity     = Dim{L: 1, T: -1}
+	Acceleration = Dim{L: 1, T: -2}
+	Force        = Dim{L: 1, M: 1, T: -2}
+	Energy       = Dim{L: 2, M: 1, T: -2}
+	Momentum     = Dim{L: 1, M: 1, T: -1}
+	AngMomentum  = Dim{L: 2, M: 1, T: -1}
+)
+
+// scale zwraca wartość jednostki wielkości d w SI
+func (s System) scale(d Dim) float64 {
+	return math.Pow(s.Length.SI, float64(d.L)) * math.Pow(s.Mass.SI, float64(d.M)) * math.Pow(s.Time.SI, float64(d.T))
+}
+
+// Convert przelicza wartość v wielkości d z układu s do układu to
+func (s System) Convert(v float64, d Dim, to System) (float64, error) {
+	if s == to {
+		return v, nil
+	}
+	if s.IsZero() || to.IsZero() || s.Henon || to.Henon {
+		return 0, fmt.Errorf("nie można przeliczyć jednostek %s na %s", s, to)
+	}
+	return v * s.scale(d) / to.scale(d), nil
+}
+
+// ToSI przelicza wartość v wielkości d na jednostki SI
+func (s System) ToSI(v float64, d Dim) (float64, error) {
+	return s.Convert(v, d, System{Length: Unit{"m", 1}, Mass: Unit{"kg", 1}, Time: Unit{"s", 1}})
+}
+
+// Label zwraca opis jednostki wielkości d, np. "AU/day^2" ("" dla jednostek umownych)
+func (s System) Label(d Dim) string {
+	if s.IsZero() {
+		return ""
+	}
+	var num, den []string
+	add := func(u Unit, p int) {
+		name := u.Name
+		if strings.ContainsAny(name, " ") {
+			name = "(" + name + ")"
+		}
+		switch {
+		case p == 1:
+			num = append(num, name)
+		case p > 1:
+			num = append(num, name+"^"+strconv.Itoa(p))
+		case p == -1:
+			den = append(den, name)
+		case p < -1:
+			den = append(den, name+"^"+strconv.Itoa(-p))
+		}
+	}
+	add(s.Mass, d.M)
+	add(s.Length, d.L)
+	add(s.Time, d.T)
+	label := strings.Join(num, "·")
+	if label == "" {
+		label = "1"
+	}
+	if len(den) > 0 {
+		label += "/" + strings.Join(den, "·")
+	}
+	return label
+}
+
+// Format zwraca wartość z jednostką, np. "1.234 AU" (bez jednostki dla jednostek umownych)
+func (s System) Format(v float64, d Dim, prec int) string {
+	text := strconv.FormatFloat(v, 'g', prec, 64)
+	if l := s.Label(d); l != "" {
+		text += " " + l
+	}
+	return text
+}
+
+func (s System) String() string {
+	switch {
+	case s.Henon:
+		return "henon"
+	case s.IsZero():
+		return "umowne"
+	}
+	return s.Length.Name + " / " + s.Mass.Name + " / " + s.Time.Name
+}